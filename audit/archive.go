@@ -0,0 +1,237 @@
+package audit
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/zricethezav/gitleaks/v3/manager"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/utils/binary"
+)
+
+// archiveKind is the format of an archive, determined by its file extension
+type archiveKind int
+
+const (
+	notArchive archiveKind = iota
+	tarArchive
+	tarGzArchive
+	zipArchive
+)
+
+// archiveKindOf returns the archive format of name based on its extension
+func archiveKindOf(name string) archiveKind {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return tarGzArchive
+	case strings.HasSuffix(lower, ".tar"):
+		return tarArchive
+	case strings.HasSuffix(lower, ".zip"), strings.HasSuffix(lower, ".jar"):
+		return zipArchive
+	}
+	return notArchive
+}
+
+// AuditArchive audits the files in the tarball or zip archive set by --archive-path. Entries are read
+// from the archive in memory, never extracted to disk. Archives nested in the archive are audited up to
+// --archive-depth levels deep. Symlinks and other entries that are not regular files are skipped so an
+// archive can not point gitleaks at files outside of it. Leaks are reported with the path of their entry
+// in the archive in place of a commit.
+func (repo *Repo) AuditArchive() error {
+	if err := repo.setupTimeout(); err != nil {
+		return err
+	}
+	if repo.cancel != nil {
+		defer repo.cancel()
+	}
+	auditTimeStart := time.Now()
+
+	archivePath := repo.Manager.Opts.ArchivePath
+	repo.Name = path.Base(archivePath)
+	kind := archiveKindOf(archivePath)
+
+	var err error
+	switch kind {
+	case zipArchive:
+		var zr *zip.ReadCloser
+		zr, err = zip.OpenReader(archivePath)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		err = repo.auditZip(&zr.Reader, "", 0)
+	case tarArchive, tarGzArchive:
+		var f *os.File
+		f, err = os.Open(archivePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		err = repo.auditTar(f, kind, "", 0)
+	default:
+		return fmt.Errorf("%s is not a supported archive, must be .tar, .tar.gz, .tgz, .zip, or .jar", archivePath)
+	}
+	if err != nil {
+		return err
+	}
+
+	repo.Manager.RecordTime(manager.AuditTime(howLong(auditTimeStart)))
+	return nil
+}
+
+// auditTar audits each entry of a tar stream. prefix is the path of the archive within its parent
+// archives and depth is how many archives deep the stream is nested.
+func (repo *Repo) auditTar(r io.Reader, kind archiveKind, prefix string, depth int) error {
+	if kind == tarGzArchive {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if repo.timeoutReached() {
+			return nil
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			log.Debugf("skipping archive entry %s, not a regular file", prefix+hdr.Name)
+			continue
+		}
+		if err := repo.auditArchiveEntry(tr, hdr.Name, hdr.Size, prefix, depth); err != nil {
+			return err
+		}
+	}
+}
+
+// auditZip audits each entry of a zip archive. prefix is the path of the archive within its parent
+// archives and depth is how many archives deep the archive is nested.
+func (repo *Repo) auditZip(zr *zip.Reader, prefix string, depth int) error {
+	for _, f := range zr.File {
+		if repo.timeoutReached() {
+			return nil
+		}
+		if !f.Mode().IsRegular() {
+			log.Debugf("skipping archive entry %s, not a regular file", prefix+f.Name)
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = repo.auditArchiveEntry(rc, f.Name, int64(f.UncompressedSize64), prefix, depth)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditArchiveEntry audits a single regular file read from an archive, size is the size of the entry
+// recorded in the archive. Nested archives are descended into if --archive-depth allows it and are
+// skipped otherwise.
+func (repo *Repo) auditArchiveEntry(r io.Reader, name string, size int64, prefix string, depth int) error {
+	entry := prefix + name
+	if repo.fileWhitelisted(name) {
+		log.Debugf("whitelisted file found, skipping audit of archive entry: %s", entry)
+		return nil
+	}
+
+	if kind := archiveKindOf(name); kind != notArchive {
+		if depth >= repo.Manager.Opts.ArchiveDepth {
+			log.Debugf("skipping nested archive %s, archive-depth reached", entry)
+			return nil
+		}
+		if kind == zipArchive {
+			// zip archives can not be streamed, their directory is at the end of the archive
+			b, ok, err := repo.readArchiveEntry(r, entry, size)
+			if err != nil || !ok {
+				return err
+			}
+			zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+			if err != nil {
+				log.Warnf("could not read nested archive %s: %v", entry, err)
+				return nil
+			}
+			return repo.auditZip(zr, entry+"/", depth+1)
+		}
+		if err := repo.auditTar(r, kind, entry+"/", depth+1); err != nil {
+			log.Warnf("could not read nested archive %s: %v", entry, err)
+		}
+		return nil
+	}
+
+	// nested archives are descended into whatever their path, only the files in them must match
+	if repo.pathExcluded(entry) {
+		return nil
+	}
+
+	// archive entries have no commit details
+	c := &object.Commit{}
+	c.Author.When = time.Unix(0, 0).UTC()
+
+	repo.archiveEntry = entry
+	if fileMatched(name, repo.config.FileRegex) {
+		repo.Manager.SendLeaks(manager.Leak{
+			Line:         "N/A",
+			Offender:     name,
+			Repo:         repo.Name,
+			Rule:         "file regex matched" + repo.config.FileRegex.String(),
+			Date:         c.Author.When,
+			File:         name,
+			ArchiveEntry: entry,
+		})
+	}
+
+	b, ok, err := repo.readArchiveEntry(r, entry, size)
+	if err != nil || !ok {
+		return err
+	}
+	content, encoding := decodeContent(b, repo.Manager.Opts.DefaultEncoding)
+	if bin, _ := binary.IsBinary(strings.NewReader(content)); bin && !repo.Manager.Opts.ScanBinary {
+		return nil
+	}
+	repo.encoding = encoding
+	inspectString(content, 1, c, repo, name)
+	return nil
+}
+
+// readArchiveEntry reads the content of entry from r. Entries larger than the file size limit are
+// not read and false is returned. The size recorded in the archive is not trusted, no more than the
+// limit is ever read from r.
+func (repo *Repo) readArchiveEntry(r io.Reader, entry string, size int64) ([]byte, bool, error) {
+	if repo.tooLarge(entry, size) {
+		return nil, false, nil
+	}
+	if limit := repo.config.Whitelist.FileSizeLimit; limit != 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, false, err
+	}
+	if repo.tooLarge(entry, int64(len(b))) {
+		return nil, false, nil
+	}
+	return b, true, nil
+}
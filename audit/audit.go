@@ -3,7 +3,9 @@ package audit
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path"
+	"sync"
 
 	"github.com/zricethezav/gitleaks/v3/manager"
 
@@ -12,36 +14,110 @@ import (
 
 // Run accepts a manager and begins an audit based on the options/configs set in the manager.
 func Run(m *manager.Manager) error {
+	WarnExpiredWhitelists(m.Config)
 	if m.Opts.OwnerPath != "" {
 		files, err := ioutil.ReadDir(m.Opts.OwnerPath)
 		if err != nil {
 			return err
 		}
+		completed := make(map[string]bool)
+		if m.Opts.Resume {
+			completed, err = m.CompletedRepos()
+			if err != nil {
+				return err
+			}
+		}
+		// repos are audited concurrently, up to --owner-threads at a time
+		semaphore := make(chan bool, howManyThreads(m.Opts.OwnerThreads))
+		errChan := make(chan error, len(files))
+		wg := sync.WaitGroup{}
 		for _, f := range files {
 			if !f.IsDir() {
 				continue
 			}
-			m.Opts.RepoPath = fmt.Sprintf("%s/%s", m.Opts.OwnerPath, f.Name())
-			if err := runHelper(NewRepo(m)); err != nil {
-				log.Warnf("%s is not a git repo, skipping", f.Name())
+			if !m.Opts.RepoSelected(f.Name()) {
+				log.Debugf("%s does not match repo-include or matches repo-exclude, skipping", f.Name())
+				continue
+			}
+			if completed[f.Name()] {
+				log.Infof("%s already audited, resuming from its report", f.Name())
+				if err := m.ResumeRepo(f.Name()); err != nil {
+					wg.Wait()
+					return err
+				}
+				continue
 			}
+			r := NewRepo(m)
+			r.path = fmt.Sprintf("%s/%s", m.Opts.OwnerPath, f.Name())
+			wg.Add(1)
+			semaphore <- true
+			go func(name string, r *Repo) {
+				defer func() {
+					<-semaphore
+					wg.Done()
+				}()
+				err := runHelper(r)
+				if _, ok := err.(openError); ok {
+					log.Warnf("%s is not a git repo, skipping", name)
+					return
+				} else if err != nil {
+					// the repo is not marked complete so --resume audits it again
+					errChan <- fmt.Errorf("could not audit %s: %v", name, err)
+					return
+				}
+				if m.Opts.Resume {
+					if err := m.CompleteRepo(name); err != nil {
+						errChan <- err
+					}
+				}
+			}(f.Name(), r)
 		}
-		return nil
+		wg.Wait()
+		close(errChan)
+		// the first error, if any
+		return <-errChan
+	}
+
+	if m.Opts.ArchivePath != "" {
+		return NewRepo(m).AuditArchive()
+	}
+
+	if m.Opts.AuditFilesystem() {
+		return NewRepo(m).AuditFiles()
+	}
+
+	if m.Opts.PipeStdin {
+		r := NewRepo(m)
+		r.Name = "stdin"
+		return r.AuditDiff(os.Stdin)
 	}
 
 	return runHelper(NewRepo(m))
 }
 
+// openError is returned by runHelper when the local repo to audit could not be opened, so owner path
+// audits can skip directories that are not git repos while failing on repos whose audit failed
+type openError struct {
+	error
+}
+
 func runHelper(r *Repo) error {
 	if r.Manager.Opts.OpenLocal() {
-		r.Name = path.Base(r.Manager.Opts.RepoPath)
+		r.Name = path.Base(r.path)
 		if err := r.Open(); err != nil {
-			return err
+			return openError{err}
+		}
+
+		if r.Manager.Opts.WorkTree {
+			return r.AuditWorkTree()
 		}
 
 		// Check if we are checking uncommitted files. This is the default behavior
 		// for a "$ gitleaks" command with no options set
 		if r.Manager.Opts.CheckUncommitted() {
+			if r.Manager.Opts.Staged {
+				return r.AuditStaged()
+			}
 			if err := r.AuditUncommitted(); err != nil {
 				return err
 			}
@@ -52,5 +128,11 @@ func runHelper(r *Repo) error {
 			return err
 		}
 	}
-	return r.Audit()
+	if err := r.Audit(); err != nil {
+		return err
+	}
+	if r.Manager.Opts.Submodules {
+		return r.AuditSubmodules()
+	}
+	return nil
 }
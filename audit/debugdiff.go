@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// debugDiffOutput is where --debug-diff dumps the content inspected. It is written to directly rather
+// than through the logger so the dump stays out of the log stream and never ends up in a report.
+var debugDiffOutput io.Writer = os.Stderr
+
+// debugDiffMux keeps the dumps of chunks inspected concurrently from interleaving
+var debugDiffMux sync.Mutex
+
+// dumpChunk is used when --debug-diff is set to dump content, the lines of filename inspected for c
+// starting at firstLine, along with the rules it is tested against. Lines are prefixed with + when c
+// added them and - when c removed them. Content is not redacted so rule authors can see why a rule
+// did not match, but the offenders of leaks already found are scrubbed.
+func (repo *Repo) dumpChunk(content string, firstLine int, removed bool, c *object.Commit, filename string) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- debug-diff repo %s commit %s file %s", repo.Name, c.Hash, repo.leakFile(filename))
+	if firstLine != 0 {
+		fmt.Fprintf(&b, " from line %d", firstLine)
+	}
+	b.WriteString("\n")
+	prefix := "+"
+	if removed {
+		prefix = "-"
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		fmt.Fprintf(&b, "%s%s\n", prefix, line)
+	}
+
+	var rules []string
+	for _, rule := range repo.config.Rules {
+		rules = append(rules, rule.Description)
+	}
+	if len(repo.config.KnownSecrets) != 0 {
+		rules = append(rules, "Known Secret")
+	}
+	if repo.Manager.Opts.DotenvMode && isDotenvFile(filename) {
+		rules = append(rules, "Dotenv Secret")
+	}
+	fmt.Fprintf(&b, "rules tested: %s\n", strings.Join(rules, ", "))
+
+	debugDiffMux.Lock()
+	defer debugDiffMux.Unlock()
+	io.WriteString(debugDiffOutput, repo.Manager.Scrub(b.String()))
+}
@@ -0,0 +1,151 @@
+package audit
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zricethezav/gitleaks/v3/manager"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// maxDiffLineLen is the longest line AuditDiff will read from a diff
+const maxDiffLineLen = 1024 * 1024
+
+// AuditDiff audits a unified diff, like the output of `git diff`, read from r. This is used with --pipe
+// so diffs computed elsewhere can be audited without a repo on disk. Only added lines are audited and
+// leaks are reported with the file named in the diff's +++ header and their line in the new version
+// of the file.
+func (repo *Repo) AuditDiff(r io.Reader) error {
+	if err := repo.setupTimeout(); err != nil {
+		return err
+	}
+	if repo.cancel != nil {
+		defer repo.cancel()
+	}
+	auditTimeStart := time.Now()
+
+	// the diff has no commit details
+	c := &object.Commit{}
+	c.Author.When = time.Unix(0, 0).UTC()
+
+	var (
+		filename  string
+		skip      bool
+		added     strings.Builder
+		firstLine int
+		line      int
+	)
+	// added lines are audited a run at a time so rules see the lines around a match
+	flush := func() {
+		if added.Len() != 0 && !skip {
+			inspectString(added.String(), firstLine, c, repo, filename)
+		}
+		added.Reset()
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxDiffLineLen)
+	for scanner.Scan() {
+		if repo.timeoutReached() {
+			break
+		}
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			flush()
+			filename = diffFileName(text[len("+++ "):])
+			skip = repo.skipDiffFile(filename, c)
+		case strings.HasPrefix(text, "--- "), strings.HasPrefix(text, "diff "):
+			flush()
+		case strings.HasPrefix(text, "@@"):
+			flush()
+			line = hunkNewStart(text)
+		case strings.HasPrefix(text, "+"):
+			if added.Len() == 0 {
+				firstLine = line
+			}
+			added.WriteString(text[1:] + "\n")
+			line++
+		case strings.HasPrefix(text, " "):
+			flush()
+			line++
+		default:
+			// removed lines and "\ No newline at end of file" markers
+			flush()
+		}
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	repo.Manager.RecordTime(manager.AuditTime(howLong(auditTimeStart)))
+	return nil
+}
+
+// skipDiffFile returns true if the added lines of filename should not be audited. A leak is sent if
+// filename matches the config's file regex.
+func (repo *Repo) skipDiffFile(filename string, c *object.Commit) bool {
+	if filename == "" {
+		// the file was deleted
+		return true
+	}
+	if repo.pathExcluded(filename) {
+		return true
+	}
+	if repo.fileWhitelisted(filename) {
+		log.Debugf("whitelisted file found, skipping audit of file: %s", filename)
+		return true
+	}
+	if fileMatched(filename, repo.config.FileRegex) {
+		repo.Manager.SendLeaks(manager.Leak{
+			Line:     "N/A",
+			Offender: filename,
+			Commit:   c.Hash.String(),
+			Repo:     repo.Name,
+			Rule:     "file regex matched" + repo.config.FileRegex.String(),
+			Date:     c.Author.When,
+			File:     filename,
+		})
+	}
+	return false
+}
+
+// diffFileName returns the path named by the +++ header of a diff. The b/ prefix git adds is removed
+// and an empty string is returned for /dev/null.
+func diffFileName(header string) string {
+	// timestamps may follow the path after a tab
+	if i := strings.Index(header, "\t"); i != -1 {
+		header = header[:i]
+	}
+	header = strings.TrimSpace(header)
+	if header == "/dev/null" {
+		return ""
+	}
+	if unquoted, err := strconv.Unquote(header); err == nil {
+		header = unquoted
+	}
+	return strings.TrimPrefix(header, "b/")
+}
+
+// hunkNewStart returns the first line of the new version of a file covered by a hunk header
+// like @@ -12,4 +14,6 @@
+func hunkNewStart(header string) int {
+	for _, field := range strings.Fields(header) {
+		if !strings.HasPrefix(field, "+") {
+			continue
+		}
+		start := strings.SplitN(field[1:], ",", 2)[0]
+		n, err := strconv.Atoi(start)
+		if err != nil {
+			return 0
+		}
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/zricethezav/gitleaks/v3/manager"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// dotenvKeyRe matches the keys of KEY=VALUE lines in dotenv files
+var dotenvKeyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// dotenvSensitiveKeyRe matches keys whose values are secrets by name, like DB_PASSWORD or STRIPE_KEY.
+// Values of these keys are flagged at a lower entropy than values of other keys.
+var dotenvSensitiveKeyRe = regexp.MustCompile(`(?i)(secret|token|passw(or)?d|pwd|key|credential|auth|private|salt|dsn)`)
+
+const (
+	// dotenvMinLength is the length a value must be to be flagged
+	dotenvMinLength = 8
+	// dotenvSensitiveEntropy is the Shannon entropy a value of a sensitive key must exceed to be flagged
+	// and dotenvEntropy the entropy values of other keys must exceed
+	dotenvSensitiveEntropy = 3.0
+	dotenvEntropy          = 4.0
+)
+
+// isDotenvFile returns true if filename is a dotenv file, like .env, .env.production, or prod.env
+func isDotenvFile(filename string) bool {
+	base := path.Base(filename)
+	return base == ".env" || strings.HasPrefix(base, ".env.") || strings.HasSuffix(base, ".env")
+}
+
+// parseDotenvLine returns the key and value of a KEY=VALUE line of a dotenv file. Lines may be
+// prefixed with export and values may be quoted. Comments, after a # in unquoted values, are
+// dropped. ok is false if line is not a KEY=VALUE line.
+func parseDotenvLine(line string) (key, value string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	line = strings.TrimPrefix(line, "export ")
+	eq := strings.IndexByte(line, '=')
+	if eq == -1 {
+		return "", "", false
+	}
+	key, value = strings.TrimSpace(line[:eq]), strings.TrimSpace(line[eq+1:])
+	if !dotenvKeyRe.MatchString(key) {
+		return "", "", false
+	}
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end != -1 {
+			return key, value[1 : end+1], true
+		}
+	}
+	if comment := strings.Index(value, " #"); comment != -1 {
+		value = strings.TrimSpace(value[:comment])
+	}
+	return key, value, true
+}
+
+// dotenvValueSecret returns true if the value of key looks like a secret. Values must be long and
+// random enough, and references to other variables, placeholders like <token>, and values with
+// spaces are never secrets.
+func dotenvValueSecret(key, value string) bool {
+	if len(value) < dotenvMinLength || strings.ContainsAny(value, " \t") || strings.HasPrefix(value, "$") ||
+		(strings.HasPrefix(value, "<") && strings.HasSuffix(value, ">")) {
+		return false
+	}
+	threshold := dotenvEntropy
+	if dotenvSensitiveKeyRe.MatchString(key) {
+		threshold = dotenvSensitiveEntropy
+	}
+	return shannonEntropy(value) > threshold
+}
+
+// inspectDotenv is used when --dotenv is set to check the KEY=VALUE lines of dotenv files for values
+// that look like secrets. Leaks are reported with their key as context so reviewers know what
+// leaked without seeing the value. This check is independent of the rules set in the config.
+func inspectDotenv(content string, firstLine int, c *object.Commit, repo *Repo, filename string,
+	surrounding func(offset int) ([]string, []string)) {
+	for i, line := range strings.Split(content, "\n") {
+		if repo.timeoutReached() {
+			return
+		}
+		key, value, ok := parseDotenvLine(line)
+		if !ok || !dotenvValueSecret(key, value) {
+			continue
+		}
+		if isOffenderWhitelisted(value, repo.config.Whitelist.OffenderHashes) ||
+			isOffenderRegexWhitelisted(value, repo.config.Whitelist.Regexes) {
+			continue
+		}
+		before, after := surrounding(i)
+		repo.Manager.SendLeaks(manager.Leak{
+			Line:          line,
+			Offender:      value,
+			Commit:        c.Hash.String(),
+			Message:       c.Message,
+			Repo:          repo.Name,
+			Rule:          "Dotenv Secret",
+			Author:        c.Author.Name,
+			Email:         c.Author.Email,
+			Date:          c.Author.When,
+			Tags:          "dotenv",
+			File:          repo.leakFile(filename),
+			LineNumber:    offsetLine(firstLine, i),
+			ArchiveEntry:  repo.archiveEntry,
+			Encoding:      repo.encoding,
+			Tag:           repo.tag,
+			Stash:         repo.stash,
+			Orphaned:      repo.orphaned,
+			Context:       key,
+			ContextBefore: before,
+			ContextAfter:  after,
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+)
+
+// Encodings that files read from disk or archives are transcoded from. UTF-8 files are audited as is.
+const (
+	encodingUTF8    = "utf-8"
+	encodingUTF16LE = "utf-16le"
+	encodingUTF16BE = "utf-16be"
+	encodingLatin1  = "latin1"
+)
+
+// byteOrderMarks are checked in order against the start of a file to detect its encoding
+var byteOrderMarks = []struct {
+	bom      []byte
+	encoding string
+}{
+	{[]byte{0xEF, 0xBB, 0xBF}, encodingUTF8},
+	{[]byte{0xFF, 0xFE}, encodingUTF16LE},
+	{[]byte{0xFE, 0xFF}, encodingUTF16BE},
+}
+
+// decodeContent transcodes a file's contents to UTF-8 so rules, which are written against UTF-8 text,
+// match secrets in files saved in other encodings, like the UTF-16 files written by Windows tools. The
+// encoding is detected from the file's byte order mark, and files without one are decoded as
+// --default-encoding. The encoding returned is empty for UTF-8 files and otherwise the encoding the
+// contents were transcoded from.
+func decodeContent(b []byte, defaultEncoding string) (string, string) {
+	encoding := defaultEncoding
+	for _, mark := range byteOrderMarks {
+		if bytes.HasPrefix(b, mark.bom) {
+			encoding, b = mark.encoding, b[len(mark.bom):]
+			break
+		}
+	}
+
+	switch encoding {
+	case encodingUTF16LE:
+		return decodeUTF16(b, binary.LittleEndian), encoding
+	case encodingUTF16BE:
+		return decodeUTF16(b, binary.BigEndian), encoding
+	case encodingLatin1:
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		return string(runes), encoding
+	}
+	return string(b), ""
+}
+
+// decodeUTF16 decodes UTF-16 encoded in the given byte order. A trailing odd byte is dropped.
+func decodeUTF16(b []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = order.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
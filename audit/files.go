@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/zricethezav/gitleaks/v3/config"
+	"github.com/zricethezav/gitleaks/v3/manager"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/utils/binary"
+)
+
+// defaultExcludes are the directories skipped when files are audited from the filesystem rather than
+// from commits, unless --no-default-excludes is set. They hold git's own objects and third party code.
+var defaultExcludes = []string{".git", "node_modules", "vendor"}
+
+// defaultExcluded returns true if path, a slash separated path, is in one of the defaultExcludes
+// directories and --no-default-excludes is not set
+func (repo *Repo) defaultExcluded(path string) bool {
+	if repo.Manager.Opts.NoDefaultExcludes {
+		return false
+	}
+	for _, dir := range strings.Split(path, "/") {
+		for _, exclude := range defaultExcludes {
+			if dir == exclude {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AuditFiles audits files on disk without a git repo. The files are either the comma separated list
+// set by --file-paths or every regular file under --scan-dir, skipping the defaultExcludes. Leaks have no
+// commit and are reported with the file's path on disk. Globs in a .gitleaksignore at the root of
+// --scan-dir, or the working directory for --file-paths, are matched against paths relative to it.
+func (repo *Repo) AuditFiles() error {
+	if err := repo.setupTimeout(); err != nil {
+		return err
+	}
+	if repo.cancel != nil {
+		defer repo.cancel()
+	}
+	auditTimeStart := time.Now()
+
+	root := repo.Manager.Opts.ScanDir
+	if root == "" {
+		root = "."
+		repo.Name = "files"
+	} else {
+		repo.Name = filepath.Base(filepath.Clean(root))
+	}
+	if err := repo.loadFilesIgnore(root); err != nil {
+		return err
+	}
+
+	var err error
+	if repo.Manager.Opts.ScanDir != "" {
+		err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if repo.timeoutReached() {
+				return filepath.SkipDir
+			}
+			if info.IsDir() {
+				if path != root && repo.defaultExcluded(info.Name()) {
+					log.Debugf("skipping %s, excluded by default", path)
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				log.Debugf("skipping %s, not a regular file", path)
+				return nil
+			}
+			return repo.auditFile(root, path)
+		})
+	} else {
+		for _, path := range strings.Split(repo.Manager.Opts.FilePaths, ",") {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+			if repo.timeoutReached() {
+				break
+			}
+			if err = repo.auditFile(root, path); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	repo.Manager.RecordTime(manager.AuditTime(howLong(auditTimeStart)))
+	return nil
+}
+
+// loadFilesIgnore loads the .gitleaksignore in root, if there is one, into the repo's config
+func (repo *Repo) loadFilesIgnore(root string) error {
+	f, err := os.Open(filepath.Join(root, config.IgnoreFile))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	ignore, err := config.ParseIgnore(f)
+	if err != nil {
+		return err
+	}
+	repo.config.Ignore = ignore
+	return nil
+}
+
+// auditFile audits the file at path on disk. Whitelisted files, files listed in the ignore file, files
+// over the file size limit, and binary files, unless --scan-binary is set, are skipped. Files are
+// transcoded to UTF-8 before they are inspected.
+func (repo *Repo) auditFile(root, path string) error {
+	// files on disk have no commit details
+	c := &object.Commit{}
+	c.Author.When = time.Unix(0, 0).UTC()
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	rel = filepath.ToSlash(rel)
+	if repo.pathExcluded(rel) {
+		return nil
+	}
+	if fileMatched(path, repo.config.Whitelist.File) || repo.config.Whitelist.FileGlobs.Match(rel) {
+		log.Debugf("whitelisted file found, skipping audit of file: %s", path)
+		return nil
+	}
+	if repo.ignored(c, rel) {
+		return nil
+	}
+	if fileMatched(path, repo.config.FileRegex) {
+		repo.Manager.SendLeaks(manager.Leak{
+			Line:     "N/A",
+			Offender: path,
+			Repo:     repo.Name,
+			Rule:     "file regex matched" + repo.config.FileRegex.String(),
+			Date:     c.Author.When,
+			File:     path,
+		})
+	}
+
+	if info, err := os.Stat(path); err == nil && repo.tooLarge(path, info.Size()) {
+		return nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	content, encoding := decodeContent(b, repo.Manager.Opts.DefaultEncoding)
+	if bin, _ := binary.IsBinary(strings.NewReader(content)); bin && !repo.Manager.Opts.ScanBinary {
+		return nil
+	}
+	repo.encoding = encoding
+	inspectString(content, 1, c, repo, path)
+	return nil
+}
@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+const (
+	// lfsPointerVersion is the first line of every git lfs pointer file.
+	// See https://github.com/git-lfs/git-lfs/blob/master/docs/spec.md
+	lfsPointerVersion = "version https://git-lfs.github.com/spec/v1"
+
+	// maxLFSPointerSize is the largest a blob can be and still be considered a pointer file.
+	maxLFSPointerSize = 1024
+
+	// maxLFSObjectSize is the largest lfs object gitleaks will load into memory and audit.
+	maxLFSObjectSize = 50 * 1024 * 1024
+)
+
+// lfsPointer contains the object id and size of a git lfs pointer file
+type lfsPointer struct {
+	oid  string
+	size int64
+}
+
+// parseLFSPointer returns the lfs pointer described by content. The second return
+// value is false if content is not an lfs pointer file.
+func parseLFSPointer(content string) (lfsPointer, bool) {
+	var p lfsPointer
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	if len(lines) < 3 || lines[0] != lfsPointerVersion {
+		return p, false
+	}
+	for _, line := range lines[1:] {
+		kv := strings.SplitN(line, " ", 2)
+		if len(kv) != 2 {
+			return p, false
+		}
+		switch kv[0] {
+		case "oid":
+			p.oid = strings.TrimPrefix(kv[1], "sha256:")
+		case "size":
+			size, err := strconv.ParseInt(kv[1], 10, 64)
+			if err != nil {
+				return p, false
+			}
+			p.size = size
+		}
+	}
+	if len(p.oid) != 64 {
+		return p, false
+	}
+	return p, true
+}
+
+// lfsObjectContents reads the contents of the lfs object referenced by p from the
+// repo's local lfs object store (.git/lfs/objects).
+func (repo *Repo) lfsObjectContents(p lfsPointer) (string, error) {
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return "", fmt.Errorf("no local lfs object store available for %s", repo.Name)
+	}
+	f, err := storage.Filesystem().Open(path.Join("lfs", "objects", p.oid[0:2], p.oid[2:4], p.oid))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := bytes.NewBuffer(nil)
+	if _, err := io.Copy(buf, f); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// inspectLFSPointer checks if content is a git lfs pointer file and, if so, audits the lfs
+// object it points to instead of the pointer. Objects that are not available locally or that are
+// too large are skipped. Returns true if content was an lfs pointer.
+func inspectLFSPointer(content string, c *object.Commit, repo *Repo, filename string) bool {
+	p, ok := parseLFSPointer(content)
+	if !ok {
+		return false
+	}
+	if p.size > maxLFSObjectSize {
+		log.Warnf("lfs object for %s is %d bytes which exceeds the %d byte limit, skipping audit of file",
+			filename, p.size, maxLFSObjectSize)
+		return true
+	}
+	objContent, err := repo.lfsObjectContents(p)
+	if err != nil {
+		log.Warnf("lfs object for %s not found locally, skipping audit of file: %v", filename, err)
+		return true
+	}
+	inspectString(objContent, 1, c, repo, filename)
+	return true
+}
+
+// inspectLFSBlob loads the blob identified by hash and audits it as an lfs pointer if it is one.
+// Returns true if the blob was an lfs pointer.
+func inspectLFSBlob(hash plumbing.Hash, c *object.Commit, repo *Repo, filename string) bool {
+	blob, err := repo.BlobObject(hash)
+	if err != nil || blob.Size > maxLFSPointerSize {
+		return false
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return false
+	}
+	defer r.Close()
+	buf := bytes.NewBuffer(nil)
+	if _, err := io.Copy(buf, r); err != nil {
+		return false
+	}
+	return inspectLFSPointer(buf.String(), c, repo, filename)
+}
@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// literalSyntax describes how comments and string literals are written in a language. It is
+// used by the lightweight tokenizer behind --string-literals-only.
+type literalSyntax struct {
+	lineComments  []string
+	blockComments [][2]string
+	// quotes are the string delimiters of a language, longest first so that python's
+	// triple quotes are matched before single quotes.
+	quotes []string
+	// raw quotes do not support escape sequences
+	raw map[string]bool
+	// multiline quotes may span multiple lines
+	multiline map[string]bool
+}
+
+var (
+	goSyntax = literalSyntax{
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{"`", `"`, "'"},
+		raw:           map[string]bool{"`": true},
+		multiline:     map[string]bool{"`": true},
+	}
+	pythonSyntax = literalSyntax{
+		lineComments: []string{"#"},
+		quotes:       []string{`"""`, "'''", `"`, "'"},
+		multiline:    map[string]bool{`"""`: true, "'''": true},
+	}
+	jsSyntax = literalSyntax{
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{"`", `"`, "'"},
+		multiline:     map[string]bool{"`": true},
+	}
+
+	literalSyntaxes = map[string]literalSyntax{
+		".go":  goSyntax,
+		".py":  pythonSyntax,
+		".js":  jsSyntax,
+		".jsx": jsSyntax,
+		".mjs": jsSyntax,
+		".ts":  jsSyntax,
+		".tsx": jsSyntax,
+	}
+)
+
+// extractStringLiterals returns only the string literals, including their quotes, found in content.
+// Code and comments are dropped while newlines are kept so that each literal stays on its original
+// line. Literals that share a line are separated by a space. The second return value is false if
+// the language of filename is not supported, in which case content should be audited in full.
+func extractStringLiterals(content, filename string) (string, bool) {
+	syntax, ok := literalSyntaxes[strings.ToLower(filepath.Ext(filename))]
+	if !ok {
+		return content, false
+	}
+
+	var (
+		out         strings.Builder
+		lineHasText bool
+	)
+	for i := 0; i < len(content); {
+		if content[i] == '\n' {
+			out.WriteByte('\n')
+			lineHasText = false
+			i++
+			continue
+		}
+		if end, ok := syntax.skipComment(content, i); ok {
+			// keep newlines from block comments so line numbers stay aligned
+			out.WriteString(strings.Repeat("\n", strings.Count(content[i:end], "\n")))
+			if strings.Contains(content[i:end], "\n") {
+				lineHasText = false
+			}
+			i = end
+			continue
+		}
+		quote := syntax.quoteAt(content, i)
+		if quote == "" {
+			i++
+			continue
+		}
+		end := syntax.literalEnd(content, i+len(quote), quote)
+		if lineHasText {
+			out.WriteByte(' ')
+		}
+		out.WriteString(content[i:end])
+		lineHasText = true
+		i = end
+	}
+	return out.String(), true
+}
+
+// skipComment returns the index just past the comment starting at i, if there is one.
+// Line comments end before their newline.
+func (syntax literalSyntax) skipComment(content string, i int) (int, bool) {
+	for _, c := range syntax.lineComments {
+		if strings.HasPrefix(content[i:], c) {
+			if n := strings.IndexByte(content[i:], '\n'); n != -1 {
+				return i + n, true
+			}
+			return len(content), true
+		}
+	}
+	for _, c := range syntax.blockComments {
+		if strings.HasPrefix(content[i:], c[0]) {
+			if n := strings.Index(content[i+len(c[0]):], c[1]); n != -1 {
+				return i + len(c[0]) + n + len(c[1]), true
+			}
+			return len(content), true
+		}
+	}
+	return 0, false
+}
+
+// quoteAt returns the string delimiter starting at i or "" if there is none
+func (syntax literalSyntax) quoteAt(content string, i int) string {
+	for _, q := range syntax.quotes {
+		if strings.HasPrefix(content[i:], q) {
+			return q
+		}
+	}
+	return ""
+}
+
+// literalEnd returns the index just past the closing quote of a literal whose contents start at i.
+// Unterminated literals end at the end of their line, or the end of content for multiline literals,
+// since diff chunks do not always contain complete literals.
+func (syntax literalSyntax) literalEnd(content string, i int, quote string) int {
+	for i < len(content) {
+		switch {
+		case content[i] == '\\' && !syntax.raw[quote]:
+			i += 2
+		case content[i] == '\n' && !syntax.multiline[quote]:
+			return i
+		case strings.HasPrefix(content[i:], quote):
+			return i + len(quote)
+		default:
+			i++
+		}
+	}
+	return len(content)
+}
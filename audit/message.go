@@ -0,0 +1,22 @@
+package audit
+
+import (
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// messageSource is the source of leaks found in commit messages when --scan-messages is set
+const messageSource = "message"
+
+// inspectMessage audits the message of commit c with the config's rules when --scan-messages is set,
+// since tokens pasted into a commit's description are as exposed as tokens in its diff. Leaks are
+// reported with the commit, without a file, and with a source of message. Line numbers are lines
+// of the message. Whitelists apply as they do to files.
+func (repo *Repo) inspectMessage(c *object.Commit) {
+	if !repo.Manager.Opts.ScanMessages {
+		return
+	}
+	// a copy is inspected since the commit's patches may be inspected concurrently
+	r := *repo
+	r.source = messageSource
+	inspectString(c.Message, 1, c, &r, "")
+}
@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"encoding/hex"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+// notesPrefix is the prefix of the refs git notes are kept in, like refs/notes/commits
+const notesPrefix = "refs/notes/"
+
+// noteSource is the source of leaks found in git notes when --notes is set
+const noteSource = "note"
+
+// noteRefs returns the repo's refs/notes/* refs
+func (repo *Repo) noteRefs() ([]*plumbing.Reference, error) {
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+	var notes []*plumbing.Reference
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if strings.HasPrefix(ref.Name().String(), notesPrefix) && ref.Type() == plumbing.HashReference {
+			notes = append(notes, ref)
+		}
+		return nil
+	})
+	return notes, err
+}
+
+// noteCommits adds the commits of refs, the commits that recorded each version of the notes, to
+// scanned so walks of all refs leave them out. Their trees are notes, not files, and are audited as
+// notes by auditNotes.
+func (repo *Repo) noteCommits(refs []*plumbing.Reference, scanned map[plumbing.Hash]bool) error {
+	for _, ref := range refs {
+		iter, err := repo.log(&git.LogOptions{From: ref.Hash()})
+		if err != nil {
+			return err
+		}
+		err = iter.ForEach(func(c *object.Commit) error {
+			scanned[c.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditNotes audits the notes of refs, the refs/notes/* refs, when --notes is set. Notes are kept
+// outside of the commits they annotate so they are not audited with them. Each note is a file named
+// by the commit it annotates, possibly split into directories like ab/cdef..., in the tree of the
+// notes ref's commit. Only the current notes are audited, not notes since removed. Leaks are
+// reported with the annotated commit, the note's ref, and a source of note.
+func (repo *Repo) auditNotes(refs []*plumbing.Reference) error {
+	defer func() {
+		repo.note, repo.source = "", ""
+	}()
+	for _, ref := range refs {
+		notes, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			log.Warnf("%s %s could not be read, skipping: %v", ref.Name(), ref.Hash(), err)
+			continue
+		}
+		tree, err := notes.Tree()
+		if err != nil {
+			return err
+		}
+		repo.note, repo.source = ref.Name().String(), noteSource
+		err = tree.Files().ForEach(func(f *object.File) error {
+			if repo.timeoutReached() {
+				return storer.ErrStop
+			}
+			annotated := strings.Replace(f.Name, "/", "", -1)
+			if _, err := hex.DecodeString(annotated); err != nil || len(annotated) != 40 {
+				log.Debugf("%s %s is not a note, skipping", ref.Name(), f.Name)
+				return nil
+			}
+			if isCommitWhiteListed(annotated, repo.config.Whitelist.Commits) {
+				return nil
+			}
+			if bin, err := f.IsBinary(); err != nil || (bin && !repo.Manager.Opts.ScanBinary) {
+				return nil
+			}
+			content, err := f.Contents()
+			if err != nil {
+				return err
+			}
+			// notes can annotate any object, those that are not commits are reported with the
+			// author and date of the note
+			c, err := repo.CommitObject(plumbing.NewHash(annotated))
+			if err != nil {
+				c = &object.Commit{Hash: plumbing.NewHash(annotated), Author: notes.Author}
+			}
+			inspectString(content, 1, c, repo, "")
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
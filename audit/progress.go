@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+// progressOutput is where --progress is printed. It is stderr so json reports written to stdout are
+// not interleaved with progress.
+var progressOutput io.Writer = os.Stderr
+
+// progressInterval is how often a progress bar is redrawn on a terminal
+const progressInterval = 200 * time.Millisecond
+
+// progressBarWidth is how many characters wide a progress bar is
+const progressBarWidth = 30
+
+// progress prints how many of a repo's commits have been audited when --progress is set. On a
+// terminal a bar is redrawn at most every progressInterval. Otherwise, like in CI logs, a line is
+// printed each time another 10 percent of the commits are audited.
+type progress struct {
+	w     io.Writer
+	name  string
+	total int
+	done  int
+	tty   bool
+	start time.Time
+	// last is when the bar was last drawn and lastPercent the percentage last printed
+	last        time.Time
+	lastPercent int
+}
+
+// newProgress returns the progress of auditing total commits of the repo name, printed to w
+func newProgress(w io.Writer, name string, total int, tty bool) *progress {
+	return &progress{w: w, name: name, total: total, tty: tty, start: time.Now(), lastPercent: -1}
+}
+
+// isTerminal returns true if w is a terminal progress can be redrawn on
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && (isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()))
+}
+
+// add records that n more commits have been audited. A nil progress, when --progress is not set,
+// does nothing.
+func (p *progress) add(n int) {
+	if p == nil {
+		return
+	}
+	p.done += n
+	// the count is taken before the audit so commits can be added to the repo while it runs
+	if p.done > p.total {
+		p.total = p.done
+	}
+	if p.tty {
+		if time.Since(p.last) >= progressInterval {
+			p.draw()
+		}
+		return
+	}
+	if percent := p.percent(); percent/10 > p.lastPercent/10 {
+		p.lastPercent = percent
+		fmt.Fprintf(p.w, "%s: %d/%d commits audited (%d%%)%s\n", p.name, p.done, p.total, percent, p.eta())
+	}
+}
+
+// finish prints the repo's final progress
+func (p *progress) finish() {
+	if p == nil {
+		return
+	}
+	if p.tty {
+		p.draw()
+		fmt.Fprintln(p.w)
+	} else if p.lastPercent != 100 {
+		fmt.Fprintf(p.w, "%s: %d/%d commits audited\n", p.name, p.done, p.total)
+	}
+}
+
+// draw redraws the progress bar over the current line
+func (p *progress) draw() {
+	p.last = time.Now()
+	filled := progressBarWidth
+	if p.total != 0 {
+		filled = progressBarWidth * p.done / p.total
+	}
+	fmt.Fprintf(p.w, "\r\033[K%s [%s%s] %d/%d commits (%d%%)%s", p.name, strings.Repeat("=", filled),
+		strings.Repeat(" ", progressBarWidth-filled), p.done, p.total, p.percent(), p.eta())
+}
+
+func (p *progress) percent() int {
+	if p.total == 0 {
+		return 100
+	}
+	return 100 * p.done / p.total
+}
+
+// eta estimates the time left from the average time taken per commit so far
+func (p *progress) eta() string {
+	if p.done == 0 || p.done == p.total {
+		return ""
+	}
+	left := time.Since(p.start) / time.Duration(p.done) * time.Duration(p.total-p.done)
+	return fmt.Sprintf(", ETA %s", left.Round(time.Second))
+}
+
+// countCommits counts the commits walks will audit, with the same commits skipped as auditLog,
+// so progress can be shown out of a total. Commits in exclude or scanned are not counted.
+func (repo *Repo) countCommits(walks []commitWalk, exclude, scanned map[plumbing.Hash]bool) (int, error) {
+	counted := make(map[plumbing.Hash]bool)
+	total := 0
+	for _, walk := range walks {
+		logOpts := *walk.logOpts
+		cIter, err := repo.log(&logOpts)
+		if err != nil {
+			return 0, err
+		}
+		err = cIter.ForEach(func(c *object.Commit) error {
+			if c.Hash.String() == repo.Manager.Opts.CommitTo ||
+				(repo.Manager.Opts.Depth != 0 && total == repo.Manager.Opts.Depth) {
+				return storer.ErrStop
+			}
+			if exclude[c.Hash] || scanned[c.Hash] || counted[c.Hash] {
+				return nil
+			}
+			counted[c.Hash] = true
+			if isCommitWhiteListed(c.Hash.String(), repo.config.Whitelist.Commits) || c.Author.When.Before(repo.since) {
+				return nil
+			}
+			total++
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,190 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/hex"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+// auditReflog audits the commits in the repo's reflogs that are no longer reachable from any ref,
+// like commits amended or rebased away, along with their ancestors that are not reachable either.
+// These commits are only kept until they expire from the reflog and are pruned. Commits in exclude
+// or scanned are skipped like they are by auditLog. Leaks found are reported as orphaned.
+func (repo *Repo) auditReflog(exclude, scanned map[plumbing.Hash]bool, cc *int) error {
+	since, err := repo.Manager.Opts.ParseReflogSince(time.Now())
+	if err != nil {
+		return err
+	}
+	commits, err := repo.reflogCommits(since)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		log.Debugf("no reflog entries found in %s", repo.Name)
+		return nil
+	}
+	tips, err := repo.refTips()
+	if err != nil {
+		return err
+	}
+	reachable, err := reachableFrom(repo, tips)
+	if err != nil {
+		return err
+	}
+	for hash := range exclude {
+		reachable[hash] = true
+	}
+
+	repo.orphaned = true
+	defer func() {
+		repo.orphaned = false
+	}()
+	for _, hash := range commits {
+		if reachable[hash] || scanned[hash] {
+			continue
+		}
+		if _, err := repo.CommitObject(hash); err != nil {
+			log.Debugf("reflog commit %s could not be read, it may have been pruned: %v", hash, err)
+			continue
+		}
+		if err := repo.auditLog(&git.LogOptions{From: hash}, reachable, scanned, cc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refTips returns the commit each ref points to, including HEAD. Annotated tags are peeled to their
+// commit and refs that do not point to a commit are skipped.
+func (repo *Repo) refTips() ([]plumbing.Hash, error) {
+	var tips []plumbing.Hash
+	if head, err := repo.Head(); err == nil {
+		tips = append(tips, head.Hash())
+	}
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		if tag, err := repo.TagObject(ref.Hash()); err == nil {
+			if c, err := tag.Commit(); err == nil {
+				tips = append(tips, c.Hash)
+			}
+		} else if _, err := repo.CommitObject(ref.Hash()); err == nil {
+			tips = append(tips, ref.Hash())
+		}
+		return nil
+	})
+	return tips, err
+}
+
+// reflogCommits reads the commits refs were updated to in every reflog of the repo, oldest first,
+// leaving out entries made before since. The stash reflog is left out since stashes are audited by
+// --stash. Repos without reflogs, like in-memory clones, have no reflog commits.
+func (repo *Repo) reflogCommits(since time.Time) ([]plumbing.Hash, error) {
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return nil, nil
+	}
+	files, err := reflogFiles(storage.Filesystem(), "logs")
+	if err != nil {
+		return nil, err
+	}
+
+	type entry struct {
+		hash plumbing.Hash
+		when time.Time
+	}
+	var entries []entry
+	seen := make(map[plumbing.Hash]bool)
+	for _, file := range files {
+		if file == "logs/"+stashRef.String() {
+			continue
+		}
+		f, err := storage.Filesystem().Open(file)
+		if err != nil {
+			return nil, err
+		}
+		// each line is "<old> <new> <committer> <time> <zone>\t<message>", oldest first
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if tab := strings.IndexByte(line, '\t'); tab != -1 {
+				line = line[:tab]
+			}
+			fields := strings.Fields(line)
+			if len(fields) < 4 || len(fields[1]) != 40 {
+				continue
+			}
+			if _, err := hex.DecodeString(fields[1]); err != nil {
+				continue
+			}
+			hash := plumbing.NewHash(fields[1])
+			// the zero hash is recorded when a ref is deleted
+			if hash.IsZero() || seen[hash] {
+				continue
+			}
+			seconds, err := strconv.ParseInt(fields[len(fields)-2], 10, 64)
+			if err != nil {
+				continue
+			}
+			if when := time.Unix(seconds, 0); !when.Before(since) {
+				seen[hash] = true
+				entries = append(entries, entry{hash: hash, when: when})
+			}
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].when.Before(entries[j].when)
+	})
+	hashes := make([]plumbing.Hash, len(entries))
+	for i, e := range entries {
+		hashes[i] = e.hash
+	}
+	return hashes, nil
+}
+
+// reflogFiles returns the reflog files under dir, logs/HEAD and a file under logs/refs for each ref
+// with a reflog, ordered by path
+func reflogFiles(fs billy.Filesystem, dir string) ([]string, error) {
+	infos, err := fs.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, info := range infos {
+		name := path.Join(dir, info.Name())
+		if !info.IsDir() {
+			files = append(files, name)
+			continue
+		}
+		nested, err := reflogFiles(fs, name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, nested...)
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,156 @@
+package audit
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	fdiff "gopkg.in/src-d/go-git.v4/plumbing/format/diff"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// defaultRenameThreshold is the percentage of lines two files must share to be considered a rename
+// when --rename-threshold is not set. This mirrors git's default similarity index of 50%.
+const defaultRenameThreshold = 50
+
+// renames holds the results of rename detection on a patch. renamed maps the file patch of a
+// renamed file's new path to the lines that changed during the rename. skip contains the file
+// patches of the old paths which no longer need to be audited.
+type renames struct {
+	renamed map[fdiff.FilePatch][]changedLine
+	skip    map[fdiff.FilePatch]bool
+}
+
+// changedLine is a line that changed during a rename. Added lines are numbered by the new
+// version of the file and removed lines by the old version.
+type changedLine struct {
+	number int
+	text   string
+}
+
+// detectRenames pairs files added in a commit with files deleted in the same commit. If a pair
+// is identical or similar enough the pair is treated as a rename so only the lines that changed
+// during the rename are audited rather than the full contents of both files. Renames are recorded
+// with the manager so leaks are reported with their file's latest path.
+// go-git does not detect renames itself so a rename otherwise appears as a whole file delete and add.
+func detectRenames(filePatches []fdiff.FilePatch, c *object.Commit, repo *Repo) renames {
+	r := renames{
+		renamed: make(map[fdiff.FilePatch][]changedLine),
+		skip:    make(map[fdiff.FilePatch]bool),
+	}
+
+	// patches are generated from the commit to its parent so a file that only has a "from"
+	// side was added by the commit and a file that only has a "to" side was deleted.
+	var added, deleted []fdiff.FilePatch
+	for _, f := range filePatches {
+		if f.IsBinary() {
+			continue
+		}
+		from, to := f.Files()
+		if from != nil && to == nil {
+			added = append(added, f)
+		} else if from == nil && to != nil {
+			deleted = append(deleted, f)
+		}
+	}
+	if len(added) == 0 || len(deleted) == 0 {
+		return r
+	}
+
+	for _, a := range added {
+		from, _ := a.Files()
+		newContent, err := blobContents(repo, from.Hash())
+		if err != nil {
+			continue
+		}
+		for _, d := range deleted {
+			if r.skip[d] {
+				continue
+			}
+			_, to := d.Files()
+			var changed []changedLine
+			if to.Hash() != from.Hash() {
+				oldContent, err := blobContents(repo, to.Hash())
+				if err != nil {
+					continue
+				}
+				var ok bool
+				if changed, ok = renameChanges(oldContent, newContent, repo.renameThreshold()); !ok {
+					continue
+				}
+			}
+			log.Debugf("rename detected %s -> %s", to.Path(), from.Path())
+			r.renamed[a] = changed
+			r.skip[d] = true
+			repo.Manager.RecordRename(repo.Name, repo.leakFile(to.Path()), repo.leakFile(from.Path()), c.Author.When)
+			break
+		}
+	}
+	return r
+}
+
+// renameChanges compares the old and new contents of a possibly renamed file. If at least threshold
+// percent of their lines are shared the file is a rename and the lines that were added or removed are
+// returned along with true.
+func renameChanges(oldContent, newContent string, threshold int) ([]changedLine, bool) {
+	oldLines := strings.Split(oldContent, "\n")
+	newLines := strings.Split(newContent, "\n")
+
+	remaining := make(map[string]int)
+	for _, line := range oldLines {
+		remaining[line]++
+	}
+	var changed []changedLine
+	common := 0
+	for i, line := range newLines {
+		if remaining[line] > 0 {
+			remaining[line]--
+			common++
+			continue
+		}
+		changed = append(changed, changedLine{number: i + 1, text: line})
+	}
+	for i, line := range oldLines {
+		if remaining[line] > 0 {
+			remaining[line]--
+			changed = append(changed, changedLine{number: i + 1, text: line})
+		}
+	}
+
+	total := len(oldLines)
+	if len(newLines) > total {
+		total = len(newLines)
+	}
+	if common*100 < threshold*total {
+		return nil, false
+	}
+	return changed, true
+}
+
+// renameThreshold returns the similarity, as a percentage of lines, files must share to be a rename
+func (repo *Repo) renameThreshold() int {
+	if repo.Manager.Opts.RenameThreshold == 0 {
+		return defaultRenameThreshold
+	}
+	return repo.Manager.Opts.RenameThreshold
+}
+
+// blobContents returns the contents of the blob identified by hash
+func blobContents(repo *Repo, hash plumbing.Hash) (string, error) {
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return "", err
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	buf := bytes.NewBuffer(nil)
+	if _, err := io.Copy(buf, r); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
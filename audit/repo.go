@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/md5"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -16,14 +17,15 @@ import (
 	"github.com/zricethezav/gitleaks/v3/manager"
 
 	"github.com/BurntSushi/toml"
-	"github.com/sergi/go-diff/diffmatchpatch"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/src-d/go-billy.v4"
 	"gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 	"gopkg.in/src-d/go-git.v4/plumbing/storer"
 	"gopkg.in/src-d/go-git.v4/storage/memory"
+	"gopkg.in/src-d/go-git.v4/utils/binary"
 )
 
 // Repo wraps a *git.Repository object in addition to a manager object and the name of the repo.
@@ -46,14 +48,67 @@ type Repo struct {
 
 	Name    string
 	Manager *manager.Manager
+
+	// path is the local path of the repo. It defaults to --repo-path and is set per repo
+	// for --owner-path audits so repos can be opened concurrently.
+	path string
+
+	// archiveEntry is the path of the archive entry being inspected when --archive-path is set.
+	// Leaks found in archives are located by their entry rather than a commit.
+	archiveEntry string
+
+	// encoding is the encoding of the file on disk or archive entry being inspected if it is not
+	// UTF-8, like utf-16le. The file's contents are transcoded to UTF-8 before they are inspected.
+	encoding string
+
+	// source is set while something other than a file is inspected, like a commit message when
+	// --scan-messages is set. It is empty for files.
+	source string
+
+	// note is the notes ref, like refs/notes/commits, whose notes are being audited when --notes is set
+	note string
+
+	// tag is the tag whose history is being walked when --tags is set. It is empty while
+	// branches are walked.
+	tag string
+
+	// stash is the stash being audited when --stash is set, like stash@{0}
+	stash string
+
+	// orphaned is set while commits only in the reflog are audited when --reflog is set
+	orphaned bool
+
+	// commitTimeout is the time allowed to audit each commit when --commit-timeout is set
+	commitTimeout time.Duration
+
+	// since is the time commits must be authored after to be audited when --since is set
+	since time.Time
+
+	// submodule is the path of the submodule being audited when --submodules is set. It is empty
+	// for the repo itself.
+	submodule string
+
+	// threads is how many goroutines inspect commits, and the files of wide commits, set by --threads
+	threads int
+
+	// progress is printed as commits are audited when --progress is set
+	progress *progress
+
+	// shallow is the set of a shallow clone's boundary commits, whose parents were not fetched, and
+	// shallowParents those parents. Both are empty for complete clones.
+	shallow        map[plumbing.Hash]bool
+	shallowParents []plumbing.Hash
 }
 
-// NewRepo initializes and returns a Repo struct.
+// NewRepo initializes and returns a Repo struct. The repo shares the manager's
+// compiled config so rules are not recompiled for every repo audited.
 func NewRepo(m *manager.Manager) *Repo {
 	return &Repo{
 		Manager: m,
 		config:  m.Config,
 		ctx:     context.Background(),
+		path:    m.Opts.RepoPath,
+		threads: howManyThreads(m.Opts.Threads),
 	}
 }
 
@@ -80,7 +135,10 @@ func (repo *Repo) Clone(cloneOption *git.CloneOptions) error {
 	if err != nil {
 		return err
 	}
-	repo.Name = filepath.Base(repo.Manager.Opts.Repo)
+	// host audits clone many repos and name each one before cloning
+	if repo.Name == "" {
+		repo.Name = filepath.Base(repo.Manager.Opts.Repo)
+	}
 	repo.Repository = repository
 	repo.Manager.RecordTime(manager.CloneTime(howLong(start)))
 
@@ -98,10 +156,16 @@ func (repo *Repo) AuditUncommitted() error {
 		}
 		repo.config = cfg
 	}
+	if err := repo.loadIgnore(); err != nil {
+		return err
+	}
 
 	if err := repo.setupTimeout(); err != nil {
 		return err
 	}
+	if repo.cancel != nil {
+		defer repo.cancel()
+	}
 
 	auditTimeStart := time.Now()
 
@@ -152,6 +216,12 @@ func (repo *Repo) AuditUncommitted() error {
 				if _, err := io.Copy(workTreeBuf, workTreeFile); err != nil {
 					return err
 				}
+				if repo.tooLarge(workTreeFile.Name(), int64(workTreeBuf.Len())) {
+					continue
+				}
+				if bin, _ := binary.IsBinary(bytes.NewReader(workTreeBuf.Bytes())); bin && !repo.Manager.Opts.ScanBinary {
+					continue
+				}
 				currFileContents = workTreeBuf.String()
 				filename = workTreeFile.Name()
 			}
@@ -171,7 +241,14 @@ func (repo *Repo) AuditUncommitted() error {
 				}
 			}
 
-			if fileMatched(filename, repo.config.Whitelist.File) {
+			if repo.pathExcluded(filename) || repo.ignored(c, filename) {
+				continue
+			}
+			if repo.defaultExcluded(filename) {
+				log.Debugf("skipping %s, excluded by default", filename)
+				continue
+			}
+			if repo.fileWhitelisted(filename) {
 				log.Debugf("whitelisted file found, skipping audit of file: %s", filename)
 			} else if fileMatched(filename, repo.config.FileRegex) {
 				repo.Manager.SendLeaks(manager.Leak{
@@ -187,18 +264,7 @@ func (repo *Repo) AuditUncommitted() error {
 					File:     filename,
 				})
 			} else {
-				dmp := diffmatchpatch.New()
-				diffs := dmp.DiffMain(prevFileContents, currFileContents, false)
-				var diffContents string
-				for _, d := range diffs {
-					switch d.Type {
-					case diffmatchpatch.DiffInsert:
-						diffContents += fmt.Sprintf("%s\n", d.Text)
-					case diffmatchpatch.DiffDelete:
-						diffContents += fmt.Sprintf("%s\n", d.Text)
-					}
-				}
-				InspectString(diffContents, c, repo, filename)
+				inspectLineDiff(prevFileContents, currFileContents, true, c, repo, filename)
 			}
 		}
 	}
@@ -210,8 +276,125 @@ func (repo *Repo) AuditUncommitted() error {
 	return nil
 }
 
+// AuditStaged audits only the changes staged in the git index, what `git diff --cached` shows. Unlike
+// AuditUncommitted, unstaged edits in the worktree are ignored. This is used with --staged as a pre-commit
+// hook. Only lines added by the staged changes are audited and leaks are reported with their line number
+// in the staged version of the file.
+func (repo *Repo) AuditStaged() error {
+	if repo.Manager.Opts.RepoConfig {
+		cfg, err := repo.loadRepoConfig()
+		if err != nil {
+			return err
+		}
+		repo.config = cfg
+	}
+	if err := repo.loadIgnore(); err != nil {
+		return err
+	}
+
+	if err := repo.setupTimeout(); err != nil {
+		return err
+	}
+	if repo.cancel != nil {
+		defer repo.cancel()
+	}
+
+	auditTimeStart := time.Now()
+
+	// staged changes are compared to HEAD, which does not exist before the first commit
+	var headTree *object.Tree
+	if ref, err := repo.Head(); err == nil {
+		c, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return err
+		}
+		headTree, err = c.Tree()
+		if err != nil {
+			return err
+		}
+	} else if err != plumbing.ErrReferenceNotFound {
+		return err
+	}
+
+	// Staged change so the commit details do not yet exist. Insert empty defaults.
+	c := &object.Commit{Message: "***STAGED CHANGES***"}
+	c.Author.When = time.Unix(0, 0).UTC()
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+	for _, e := range idx.Entries {
+		if repo.timeoutReached() {
+			break
+		}
+		// entries with a stage are unresolved merge conflicts
+		if e.Mode == filemode.Submodule || e.Stage != 0 {
+			continue
+		}
+
+		var prevFileContents string
+		if headTree != nil {
+			if prevFile, err := headTree.File(e.Name); err == nil {
+				if prevFile.Hash == e.Hash {
+					// not staged
+					continue
+				}
+				prevFileContents, err = prevFile.Contents()
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if repo.pathExcluded(e.Name) {
+			continue
+		}
+		if repo.fileWhitelisted(e.Name) {
+			log.Debugf("whitelisted file found, skipping audit of file: %s", e.Name)
+			continue
+		}
+		if repo.ignored(c, e.Name) {
+			continue
+		}
+		if fileMatched(e.Name, repo.config.FileRegex) {
+			repo.Manager.SendLeaks(manager.Leak{
+				Line:     "N/A",
+				Offender: e.Name,
+				Commit:   c.Hash.String(),
+				Repo:     repo.Name,
+				Rule:     "file regex matched" + repo.config.FileRegex.String(),
+				Message:  c.Message,
+				Date:     c.Author.When,
+				File:     e.Name,
+			})
+		}
+
+		blob, err := repo.BlobObject(e.Hash)
+		if err != nil {
+			return err
+		}
+		if repo.tooLarge(e.Name, blob.Size) {
+			continue
+		}
+		stagedFile := object.NewFile(e.Name, e.Mode, blob)
+		if bin, err := stagedFile.IsBinary(); err != nil || (bin && !repo.Manager.Opts.ScanBinary) {
+			continue
+		}
+		currFileContents, err := stagedFile.Contents()
+		if err != nil {
+			return err
+		}
+
+		inspectLineDiff(prevFileContents, currFileContents, false, c, repo, e.Name)
+	}
+
+	repo.Manager.RecordTime(manager.AuditTime(howLong(auditTimeStart)))
+	return nil
+}
+
 // Audit is responsible for scanning the entire history (default behavior) of a
-// git repo. Options that can change the behavior of this function include: --commit, --depth, --branch.
+// git repo. Options that can change the behavior of this function include: --commit, --depth, --branch, --tags.
 // See options/options.go for an explanation on these options.
 func (repo *Repo) Audit() error {
 	if err := repo.setupTimeout(); err != nil {
@@ -233,6 +416,17 @@ func (repo *Repo) Audit() error {
 		}
 		repo.config = cfg
 	}
+	if err := repo.loadIgnore(); err != nil {
+		return err
+	}
+	if err := repo.loadShallow(); err != nil {
+		return err
+	}
+	since, err := repo.Manager.Opts.ParseSince(time.Now())
+	if err != nil {
+		return err
+	}
+	repo.since = since
 
 	auditTimeStart := time.Now()
 
@@ -244,38 +438,262 @@ func (repo *Repo) Audit() error {
 		return inspectCommit(repo.Manager.Opts.FilesAtCommit, repo, inspectFilesAtCommit)
 	}
 
-	logOpts, err := getLogOptions(repo)
+	var (
+		logOpts *git.LogOptions
+		exclude map[plumbing.Hash]bool
+	)
+	if repo.Manager.Opts.BaseBranch != "" {
+		logOpts, exclude, err = getMergeBaseLogOptions(repo)
+	} else if repo.Manager.Opts.Revision != "" {
+		logOpts, exclude, err = getRevisionLogOptions(repo)
+	} else {
+		logOpts, err = getLogOptions(repo)
+	}
 	if err != nil {
 		return err
 	}
-	cIter, err := repo.Log(logOpts)
+	var tips map[string]plumbing.Hash
+	if repo.Manager.Opts.StateFile != "" {
+		if tips, err = repo.auditTips(); err != nil {
+			return err
+		}
+		if exclude, err = repo.auditedCommits(tips); err != nil {
+			return err
+		}
+	}
+	walks := []commitWalk{{logOpts: logOpts}}
+	if branches := repo.Manager.Opts.Branches(); len(branches) > 1 {
+		// the branches are walked in turn and commits they share are only audited by the first
+		// walk to reach them, so leaks are labeled with every branch that reaches their commit
+		more, err := namedBranchWalks(repo, branches[1:])
+		if err != nil {
+			return err
+		}
+		walks = append(walks, more...)
+		reach, err := branchReach(repo, branches)
+		if err != nil {
+			return err
+		}
+		repo.Manager.RecordBranches(repo.Name, reach)
+	}
+	if repo.Manager.Opts.Tags {
+		if logOpts.All {
+			// walking all refs would reach tagged commits from their tags before the branches
+			// are done, so branches are walked one at a time ahead of the tags
+			walks, err = branchWalks(repo)
+			if err != nil {
+				return err
+			}
+		}
+		tags, err := tagWalks(repo)
+		if err != nil {
+			return err
+		}
+		walks = append(walks, tags...)
+	}
+
+	cc := 0
+	scanned := make(map[plumbing.Hash]bool)
+	var stashes []stashEntry
+	if repo.Manager.Opts.Stash {
+		if stashes, err = repo.stashes(); err != nil {
+			return err
+		}
+		if len(stashes) == 0 {
+			log.Debugf("no stashes found in %s", repo.Name)
+		}
+		// stashes are reachable from refs/stash so their commits are left out of the walks and
+		// audited as stashes instead
+		for _, stash := range stashes {
+			for _, hash := range stashCommits(stash) {
+				scanned[hash] = true
+			}
+		}
+	}
+	var notes []*plumbing.Reference
+	if repo.Manager.Opts.Notes {
+		if notes, err = repo.noteRefs(); err != nil {
+			return err
+		}
+		if len(notes) == 0 {
+			log.Debugf("no notes found in %s", repo.Name)
+		}
+		if err := repo.noteCommits(notes, scanned); err != nil {
+			return err
+		}
+	}
+	if repo.Manager.Opts.Progress {
+		total, err := repo.countCommits(walks, exclude, scanned)
+		if err != nil {
+			return err
+		}
+		repo.progress = newProgress(progressOutput, repo.Name, total+len(stashes), isTerminal(progressOutput))
+	}
+	for _, walk := range walks {
+		repo.tag = walk.tag
+		if err := repo.auditLog(walk.logOpts, exclude, scanned, &cc); err != nil {
+			return err
+		}
+	}
+	repo.tag = ""
+	if repo.Manager.Opts.Reflog {
+		if err := repo.auditReflog(exclude, scanned, &cc); err != nil {
+			return err
+		}
+	}
+	if err := repo.auditStashes(stashes); err != nil {
+		return err
+	}
+	if err := repo.auditNotes(notes); err != nil {
+		return err
+	}
+	cc += len(stashes)
+	repo.progress.add(len(stashes))
+	repo.progress.finish()
+
+	// an audit cut short has not audited everything up to the tips
+	if tips != nil && !repo.timeoutReached() {
+		repo.recordTips(tips)
+	}
+
+	repo.Manager.RecordTime(manager.AuditTime(howLong(auditTimeStart)))
+	repo.Manager.IncrementCommits(cc)
+	return nil
+}
+
+// patchQueueLen is how many patches per worker can be queued while commits are walked
+const patchQueueLen = 8
+
+// patchJob is a commit's patch queued for inspection by auditLog's workers. budget is what is
+// left of the commit's time when --commit-timeout is set.
+type patchJob struct {
+	commit *object.Commit
+	patch  *object.Patch
+	budget time.Duration
+}
+
+// errCommitTimeout is returned by commitPatch when a commit exceeds --commit-timeout
+var errCommitTimeout = errors.New("commit timeout exceeded")
+
+// commitPatch generates the patch between c and parent. When --commit-timeout is set and the
+// commit's time, counted from start, runs out first errCommitTimeout is returned.
+func (repo *Repo) commitPatch(c, parent *object.Commit, start time.Time) (*object.Patch, error) {
+	if repo.commitTimeout == 0 {
+		return c.Patch(parent)
+	}
+	ctx, cancel := context.WithDeadline(repo.ctx, start.Add(repo.commitTimeout))
+	defer cancel()
+	patch, err := c.PatchContext(ctx, parent)
+	if err != nil && ctx.Err() == context.DeadlineExceeded && !repo.timeoutReached() {
+		return nil, errCommitTimeout
+	}
+	return patch, err
+}
+
+// withinCommitTimeout runs audit on a copy of repo that times out after budget, or sooner if the
+// audit's own timeout is reached first. The commit is recorded as skipped if budget runs out.
+// audit is run on repo itself when --commit-timeout is not set.
+func (repo *Repo) withinCommitTimeout(c *object.Commit, budget time.Duration, audit func(r *Repo)) {
+	if repo.commitTimeout == 0 {
+		audit(repo)
+		return
+	}
+	if budget <= 0 {
+		repo.Manager.SkipCommit(c.Hash.String())
+		return
+	}
+	r := *repo
+	var cancel context.CancelFunc
+	r.ctx, cancel = context.WithTimeout(repo.ctx, budget)
+	defer cancel()
+	audit(&r)
+	if r.timeoutReached() && !repo.timeoutReached() {
+		repo.Manager.SkipCommit(c.Hash.String())
+	}
+}
+
+// inspectionReadsStorage returns true if inspecting patches reads blobs from the repo's storage, which
+// is not safe for concurrent use. Rename detection, lfs, binary files, and file sizes all read blobs, so
+// with any of them set patches, and the files of wide commits, are inspected one at a time.
+func (repo *Repo) inspectionReadsStorage() bool {
+	return repo.Manager.Opts.FollowRenames || repo.Manager.Opts.LFS || repo.Manager.Opts.ScanBinary ||
+		repo.config.Whitelist.FileSizeLimit != 0
+}
+
+// auditLog inspects the commits walked by logOpts. Commits in exclude or scanned are skipped and
+// the commits inspected are added to scanned so a commit reachable from several walks is only
+// inspected once. cc counts the commits inspected across walks for --depth. Inspections started by
+// the walk are finished before auditLog returns. Errors reading commits or generating their patches
+// stop the walk and are returned.
+func (repo *Repo) auditLog(logOpts *git.LogOptions, exclude, scanned map[plumbing.Hash]bool, cc *int) error {
+	if repo.Manager.Opts.TraversalOrder != "topo" {
+		logOpts.Order = git.LogOrderCommitterTime
+	}
+	cIter, err := repo.log(logOpts)
 	if err != nil {
 		return err
 	}
+	if repo.Manager.Opts.TraversalOrder == "topo" {
+		cIter, err = topoOrder(cIter, repo.Manager.Opts.CommitTo)
+		if err != nil {
+			return err
+		}
+	}
 
-	cc := 0
-	semaphore := make(chan bool, howManyThreads(repo.Manager.Opts.Threads))
+	// patches are generated as commits are walked since the repo's storage is not safe for
+	// concurrent use, then inspected by a pool of --threads workers
+	threads := repo.threads
+	patches := make(chan patchJob, threads*patchQueueLen)
 	wg := sync.WaitGroup{}
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range patches {
+				repo.withinCommitTimeout(job.commit, job.budget, func(r *Repo) {
+					inspectPatch(job.patch, job.commit, r)
+				})
+			}
+		}()
+	}
+
 	err = cIter.ForEach(func(c *object.Commit) error {
 		if c == nil || c.Hash.String() == repo.Manager.Opts.CommitTo ||
-			repo.timeoutReached() || repo.depthReached(cc) {
+			repo.timeoutReached() || repo.depthReached(*cc) {
 			return storer.ErrStop
 		}
-
-		if len(c.ParentHashes) == 0 {
-			cc++
-			err = inspectFilesAtCommit(c, repo)
-			if err != nil {
-				return err
-			}
+		if exclude[c.Hash] || scanned[c.Hash] {
 			return nil
 		}
+		scanned[c.Hash] = true
 
 		if isCommitWhiteListed(c.Hash.String(), repo.config.Whitelist.Commits) {
 			return nil
 		}
+		// author dates are not ordered, a commit can be authored before its parents were, so the
+		// walk continues past old commits rather than stopping at the first one
+		if c.Author.When.Before(repo.since) {
+			return nil
+		}
+		repo.inspectMessage(c)
+
+		// the boundary commits of a shallow clone are audited like root commits since their
+		// parents were not fetched
+		if len(c.ParentHashes) == 0 || repo.shallow[c.Hash] {
+			*cc++
+			repo.progress.add(1)
+			repo.withinCommitTimeout(c, repo.commitTimeout, func(r *Repo) {
+				err = inspectFilesAtCommit(c, r)
+			})
+			if err != nil {
+				return err
+			}
+			return nil
+		}
 
-		cc++
+		*cc++
+		repo.progress.add(1)
+		commitStart := time.Now()
 		err = c.Parents().ForEach(func(parent *object.Commit) error {
 			defer func() {
 				if err := recover(); err != nil {
@@ -289,37 +707,42 @@ func (repo *Repo) Audit() error {
 				return nil
 			}
 			start := time.Now()
-			patch, err := c.Patch(parent)
-			if err != nil {
-				return fmt.Errorf("could not generate patch")
+			patch, err := repo.commitPatch(c, parent, commitStart)
+			if err == errCommitTimeout {
+				repo.Manager.SkipCommit(c.Hash.String())
+				return storer.ErrStop
+			} else if err != nil {
+				return fmt.Errorf("could not generate patch of commit %s: %v", c.Hash, err)
 			}
 			repo.Manager.RecordTime(manager.PatchTime(howLong(start)))
-			wg.Add(1)
-			semaphore <- true
-			go func(c *object.Commit, patch *object.Patch) {
-				defer func() {
-					<-semaphore
-					wg.Done()
-				}()
-				inspectPatch(patch, c, repo)
-			}(c, patch)
-
+			// patches are inspected within what is left of the commit's time
+			budget := repo.commitTimeout - time.Since(commitStart)
+			if repo.inspectionReadsStorage() {
+				// the repo's storage is not safe for concurrent use so these patches are inspected as
+				// the commits are walked
+				repo.withinCommitTimeout(c, budget, func(r *Repo) {
+					inspectPatch(patch, c, r)
+				})
+				return nil
+			}
+			patches <- patchJob{commit: c, patch: patch, budget: budget}
 			return nil
 		})
-		return nil
+		return err
 	})
 
+	// leaks are reported with repo.tag so the walk's inspections must finish before the next walk,
+	// even if the walk failed
+	close(patches)
 	wg.Wait()
-	repo.Manager.RecordTime(manager.AuditTime(howLong(auditTimeStart)))
-	repo.Manager.IncrementCommits(cc)
-	return nil
+	return err
 }
 
 // Open opens a local repo either from repo-path or $PWD
 func (repo *Repo) Open() error {
-	if repo.Manager.Opts.RepoPath != "" {
+	if repo.path != "" {
 		// open git repo from repo path
-		repository, err := git.PlainOpen(repo.Manager.Opts.RepoPath)
+		repository, err := git.PlainOpen(repo.path)
 		if err != nil {
 			return err
 		}
@@ -356,40 +779,155 @@ func (repo *Repo) loadRepoConfig() (config.Config, error) {
 	defer f.Close()
 	var tomlLoader config.TomlLoader
 	_, err = toml.DecodeReader(f, &tomlLoader)
-	return tomlLoader.Parse()
+	cfg, err := tomlLoader.Parse()
+	if err != nil {
+		return cfg, err
+	}
+	// known secrets, the path regex, and tag filters are set by cli options so carry them over to the
+	// repo config
+	cfg.KnownSecrets = repo.Manager.Config.KnownSecrets
+	cfg.PathRegex = repo.Manager.Config.PathRegex
+	// repo configs need not have every rule named by --enable-rules or --disable-rules
+	cfg.FilterRules(repo.Manager.Opts.EnableRules, repo.Manager.Opts.DisableRules)
+	cfg.FilterTags(repo.Manager.Opts.IncludeTags, repo.Manager.Opts.ExcludeTags)
+	WarnExpiredWhitelists(cfg)
+	return cfg, nil
 }
 
-// timeoutReached returns true if the timeout deadline has been met. This function should be used
-// at the top of loops and before potentially long running goroutines (like checking inefficient regexes)
+// loadIgnore loads the repo's .gitleaksignore into its config. The file is read from the worktree
+// or, for repos cloned without one, from HEAD. Repos without the file ignore nothing.
+func (repo *Repo) loadIgnore() error {
+	var r io.ReadCloser
+	if wt, err := repo.Worktree(); err == nil {
+		f, err := wt.Filesystem.Open(config.IgnoreFile)
+		if err != nil {
+			return nil
+		}
+		r = f
+	} else {
+		ref, err := repo.Head()
+		if err != nil {
+			return nil
+		}
+		c, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return err
+		}
+		f, err := c.File(config.IgnoreFile)
+		if err != nil {
+			return nil
+		}
+		if r, err = f.Reader(); err != nil {
+			return err
+		}
+	}
+	defer r.Close()
+	ignore, err := config.ParseIgnore(r)
+	if err != nil {
+		return err
+	}
+	repo.config.Ignore = ignore
+	return nil
+}
+
+// tooLarge returns true if a file of size bytes exceeds the config's whitelisted file size limit.
+// Skipped files are recorded with the manager.
+func (repo *Repo) tooLarge(filename string, size int64) bool {
+	limit := repo.config.Whitelist.FileSizeLimit
+	if limit == 0 || size <= limit {
+		return false
+	}
+	log.Debugf("file is %d bytes which exceeds the %d byte file size limit, skipping audit of file: %s", size, limit, filename)
+	repo.Manager.SkipFile(filename)
+	return true
+}
+
+// pathExcluded returns true if --path-regex is set and filename does not match it. Excluded files are
+// skipped before anything else, so they are not checked against the config's file regex either.
+func (repo *Repo) pathExcluded(filename string) bool {
+	if repo.config.PathRegex == nil || repo.config.PathRegex.MatchString(filename) {
+		return false
+	}
+	log.Debugf("file does not match path-regex, skipping audit of file: %s", filename)
+	return true
+}
+
+// ignored returns true if filename in commit c is listed in the repo's .gitleaksignore
+func (repo *Repo) ignored(c *object.Commit, filename string) bool {
+	if repo.config.Ignore.Ignored(c.Hash.String(), filename) {
+		log.Debugf("file listed in %s, skipping audit of file: %s", config.IgnoreFile, filename)
+		return true
+	}
+	return false
+}
+
+// timeoutReached returns true if the timeout deadline has been met or --max-leaks leaks have been
+// found. This function should be used at the top of loops and before potentially long running
+// goroutines (like checking inefficient regexes)
 func (repo *Repo) timeoutReached() bool {
 	if repo.ctx.Err() == context.DeadlineExceeded {
 		return true
 	}
-	return false
+	return repo.Manager.Truncated()
 }
 
 // setupTimeout parses the --timeout option and assigns a context with timeout to the manager
-// which will exit early if the timeout has been met.
+// which will exit early if the timeout has been met. The context is also cancelled once --max-leaks
+// leaks have been found.
 func (repo *Repo) setupTimeout() error {
-	if repo.Manager.Opts.Timeout == "" {
-		return nil
+	if repo.Manager.Opts.CommitTimeout != "" {
+		commitTimeout, err := time.ParseDuration(repo.Manager.Opts.CommitTimeout)
+		if err != nil {
+			return err
+		}
+		repo.commitTimeout = commitTimeout
 	}
-	timeout, err := time.ParseDuration(repo.Manager.Opts.Timeout)
-	if err != nil {
-		return err
+	if repo.Manager.Opts.Timeout != "" {
+		timeout, err := time.ParseDuration(repo.Manager.Opts.Timeout)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		repo.ctx, repo.cancel = ctx, cancel
+
+		go func() {
+			select {
+			case <-ctx.Done():
+				if ctx.Err() == context.DeadlineExceeded {
+					log.Warnf("Timeout deadline (%s) exceeded for %s", timeout.String(), repo.Name)
+				}
+			}
+		}()
+	}
+	if repo.Manager.Opts.MaxLeaks != 0 {
+		repo.stopAtLeakLimit()
 	}
+	return nil
+}
 
-	repo.ctx, repo.cancel = context.WithTimeout(context.Background(), timeout)
+// stopAtLeakLimit cancels the repo's context once the manager has collected --max-leaks leaks so
+// patches being generated are abandoned and the walk and its workers stop.
+func (repo *Repo) stopAtLeakLimit() {
+	ctx, cancel := context.WithCancel(repo.ctx)
+	if timeoutCancel := repo.cancel; timeoutCancel != nil {
+		repo.cancel = func() {
+			cancel()
+			timeoutCancel()
+		}
+	} else {
+		repo.cancel = cancel
+	}
+	repo.ctx = ctx
 
 	go func() {
 		select {
-		case <-repo.ctx.Done():
-			if repo.timeoutReached() {
-				log.Warnf("Timeout deadline (%s) exceeded for %s", timeout.String(), repo.Name)
-			}
+		case <-repo.Manager.LeakLimit():
+			log.Warnf("Leak limit (%d) reached for %s, stopping audit", repo.Manager.Opts.MaxLeaks, repo.Name)
+			cancel()
+		case <-ctx.Done():
 		}
 	}()
-	return nil
 }
 
 func (repo *Repo) depthReached(i int) bool {
@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/zricethezav/gitleaks/v3/config"
+	"github.com/zricethezav/gitleaks/v3/manager"
+	"github.com/zricethezav/gitleaks/v3/options"
+)
+
+// Scan audits the target set in opts, like a repo, owner path, archive, or files, and returns the
+// leaks found. It is for using gitleaks as a library: the config and manager are built from opts, no
+// report or baseline is written, and interrupts are left to the caller. Git host audits are not
+// supported since the hosts package builds on this one.
+func Scan(opts options.Options) ([]manager.Leak, error) {
+	if err := opts.Guard(); err != nil {
+		return nil, err
+	}
+	if opts.AuditHost() {
+		return nil, fmt.Errorf("scan does not support host audits, use hosts.Run")
+	}
+	// leaks are returned rather than written
+	opts.Report = ""
+	opts.Stream = false
+	opts.CountOnly = false
+	opts.BaselineUpdate = false
+	opts.Resume = false
+
+	cfg, err := config.NewConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	m, err := manager.NewManager(opts, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+
+	if opts.Disk {
+		dir, err := ioutil.TempDir("", "gitleaks")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		m.CloneDir = dir
+	}
+
+	if err := Run(m); err != nil {
+		return nil, err
+	}
+	return m.GetLeaks(), nil
+}
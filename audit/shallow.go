@@ -0,0 +1,68 @@
+package audit
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// loadShallow records the boundary commits of a shallow clone, like the depth 1 clones CI checks
+// out, whose parents were not fetched. History before the boundary can not be audited so a warning
+// is logged, and the boundary commits are audited like root commits, every file at the commit.
+func (repo *Repo) loadShallow() error {
+	hashes, err := repo.Storer.Shallow()
+	if err != nil {
+		return err
+	}
+	repo.shallow = make(map[plumbing.Hash]bool)
+	repo.shallowParents = nil
+	for _, hash := range hashes {
+		c, err := repo.CommitObject(hash)
+		if err != nil {
+			continue
+		}
+		for _, parent := range c.ParentHashes {
+			if _, err := repo.CommitObject(parent); err != nil {
+				repo.shallow[hash] = true
+				repo.shallowParents = append(repo.shallowParents, parent)
+			}
+		}
+	}
+	if len(repo.shallow) != 0 {
+		log.Warnf("%s is a shallow clone so its history is incomplete, commits before its shallow boundary are not "+
+			"audited. Fetch the full history, like with git fetch --unshallow, to audit them", repo.Name)
+	}
+	return nil
+}
+
+// log returns the commits walked by logOpts like git.Repository.Log. The parents of a shallow
+// clone's boundary commits are not in the repo so they are left out of the walk, which would
+// otherwise fail on reaching them.
+func (repo *Repo) log(logOpts *git.LogOptions) (object.CommitIter, error) {
+	if len(repo.shallowParents) == 0 {
+		return repo.Log(logOpts)
+	}
+	walk := func(c *object.Commit) object.CommitIter {
+		if logOpts.Order == git.LogOrderCommitterTime {
+			return object.NewCommitIterCTime(c, nil, repo.shallowParents)
+		}
+		return object.NewCommitPreorderIter(c, nil, repo.shallowParents)
+	}
+	if logOpts.All {
+		return object.NewCommitAllIter(repo.Storer, walk)
+	}
+	from := logOpts.From
+	if from.IsZero() {
+		head, err := repo.Head()
+		if err != nil {
+			return nil, err
+		}
+		from = head.Hash()
+	}
+	c, err := repo.CommitObject(from)
+	if err != nil {
+		return nil, err
+	}
+	return walk(c), nil
+}
@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zricethezav/gitleaks/v3/manager"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+// stashRef is the ref git stash keeps the latest stash in. Older stashes are only in its reflog.
+const stashRef = plumbing.ReferenceName("refs/stash")
+
+// stashEntry is a stash, stash@{index}, and its commit
+type stashEntry struct {
+	index  int
+	commit *object.Commit
+}
+
+// stashes returns the repo's stashes, newest first, from the reflog of refs/stash. Repos without a
+// reflog, like in-memory clones, only have their latest stash.
+func (repo *Repo) stashes() ([]stashEntry, error) {
+	hashes, err := repo.stashReflog()
+	if err != nil {
+		return nil, err
+	}
+	if hashes == nil {
+		ref, err := repo.Reference(stashRef, true)
+		if err == plumbing.ErrReferenceNotFound {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		hashes = []plumbing.Hash{ref.Hash()}
+	}
+
+	var stashes []stashEntry
+	for i, hash := range hashes {
+		c, err := repo.CommitObject(hash)
+		if err != nil {
+			log.Warnf("stash@{%d} %s could not be read, skipping: %v", i, hash, err)
+			continue
+		}
+		stashes = append(stashes, stashEntry{index: i, commit: c})
+	}
+	return stashes, nil
+}
+
+// stashReflog reads the stash commits from the reflog of refs/stash, newest first. It returns nil
+// if the repo has no stash reflog.
+func (repo *Repo) stashReflog() ([]plumbing.Hash, error) {
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return nil, nil
+	}
+	f, err := storage.Filesystem().Open("logs/" + stashRef.String())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// each line is "<old> <new> <committer> <time> <zone>\t<message>", oldest first
+	var hashes []plumbing.Hash
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || len(fields[1]) != 40 {
+			continue
+		}
+		if _, err := hex.DecodeString(fields[1]); err != nil {
+			continue
+		}
+		hashes = append([]plumbing.Hash{plumbing.NewHash(fields[1])}, hashes...)
+	}
+	return hashes, scanner.Err()
+}
+
+// stashCommits returns the commits of stash. A stash's commit records the worktree and its parents
+// are the commit the stash was made on, the index, and, for git stash -u, the untracked files.
+func stashCommits(stash stashEntry) []plumbing.Hash {
+	return append([]plumbing.Hash{stash.commit.Hash}, stash.commit.ParentHashes[1:]...)
+}
+
+// auditStashes audits the changes in each stash. The worktree's changes are the diff of the stash's
+// commit against the commit it was made on and untracked files stashed with -u are audited whole.
+// The index is not audited on its own since the worktree includes staged changes. Leaks are labelled
+// with their stash, like stash@{0}.
+func (repo *Repo) auditStashes(stashes []stashEntry) error {
+	defer func() {
+		repo.stash = ""
+	}()
+	for _, stash := range stashes {
+		if repo.timeoutReached() {
+			return nil
+		}
+		c := stash.commit
+		if len(c.ParentHashes) < 2 {
+			log.Warnf("stash@{%d} %s is not a stash commit, skipping", stash.index, c.Hash)
+			continue
+		}
+		repo.stash = fmt.Sprintf("stash@{%d}", stash.index)
+
+		parent, err := repo.CommitObject(c.ParentHashes[0])
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		patch, err := c.Patch(parent)
+		if err != nil {
+			return fmt.Errorf("could not generate patch")
+		}
+		repo.Manager.RecordTime(manager.PatchTime(howLong(start)))
+		inspectPatch(patch, c, repo)
+
+		if len(c.ParentHashes) > 2 {
+			untracked, err := repo.CommitObject(c.ParentHashes[2])
+			if err != nil {
+				return err
+			}
+			if err := inspectFilesAtCommit(untracked, repo); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"path"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// stateKey returns the name the repo's branch tips are recorded under in --state-file. Submodules
+// are recorded under their path in the repo.
+func (repo *Repo) stateKey() string {
+	return path.Join(repo.Name, repo.submodule)
+}
+
+// auditTips returns the commit at the tip of each ref the audit walks, by ref name. Only the
+// branches set by --branch are walked when it is set, otherwise HEAD and every local and remote
+// branch are, along with tags when --tags is set. Annotated tags are peeled to their commit.
+func (repo *Repo) auditTips() (map[string]plumbing.Hash, error) {
+	tips := make(map[string]plumbing.Hash)
+	if branches := repo.Manager.Opts.Branches(); len(branches) != 0 {
+		for _, branch := range branches {
+			hash, err := resolveBranch(repo, branch)
+			if err != nil {
+				return nil, err
+			}
+			tips[branch] = hash
+		}
+		return tips, nil
+	}
+	if head, err := repo.Head(); err == nil {
+		tips[plumbing.HEAD.String()] = head.Hash()
+	}
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		switch {
+		case ref.Name().IsBranch(), ref.Name().IsRemote():
+			tips[ref.Name().String()] = ref.Hash()
+		case ref.Name().IsTag() && repo.Manager.Opts.Tags:
+			if tag, err := repo.TagObject(ref.Hash()); err == nil {
+				if c, err := tag.Commit(); err == nil {
+					tips[ref.Name().String()] = c.Hash
+				}
+			} else if _, err := repo.CommitObject(ref.Hash()); err == nil {
+				tips[ref.Name().String()] = ref.Hash()
+			}
+		}
+		return nil
+	})
+	return tips, err
+}
+
+// auditedCommits returns the commits audited by the repo's last audit recorded in --state-file so
+// they can be skipped. If a ref was force-pushed since, the tip recorded for it is no longer in its
+// history and nil is returned so the repo's full history is audited. Refs that have been deleted
+// are ignored.
+func (repo *Repo) auditedCommits(tips map[string]plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	recorded := repo.Manager.AuditedTips(repo.stateKey())
+	refs := make([]string, 0, len(recorded))
+	for ref := range recorded {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+
+	var from []plumbing.Hash
+	for _, ref := range refs {
+		tip, ok := tips[ref]
+		if !ok {
+			continue
+		}
+		rewritten, err := repo.rewritten(recorded[ref], tip)
+		if err != nil {
+			return nil, err
+		}
+		if rewritten {
+			log.Warnf("%s was rewritten in %s since it was last audited at %s, auditing the full history",
+				ref, repo.stateKey(), recorded[ref])
+			return nil, nil
+		}
+		from = append(from, plumbing.NewHash(recorded[ref]))
+	}
+	return reachableFrom(repo, from)
+}
+
+// rewritten returns true if the audited commit is no longer in the history of tip, either because
+// it is not an ancestor of tip or because it has been pruned from the repo
+func (repo *Repo) rewritten(audited string, tip plumbing.Hash) (bool, error) {
+	c, err := repo.CommitObject(plumbing.NewHash(audited))
+	if err == plumbing.ErrObjectNotFound {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	if c.Hash == tip {
+		return false, nil
+	}
+	tipCommit, err := repo.CommitObject(tip)
+	if err != nil {
+		return false, err
+	}
+	ancestor, err := c.IsAncestor(tipCommit)
+	return !ancestor, err
+}
+
+// recordTips records tips as the repo's audited refs in --state-file
+func (repo *Repo) recordTips(tips map[string]plumbing.Hash) {
+	recorded := make(map[string]string)
+	for ref, hash := range tips {
+		recorded[ref] = hash.String()
+	}
+	repo.Manager.RecordTips(repo.stateKey(), recorded)
+}
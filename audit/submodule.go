@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"path"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// AuditSubmodules audits the submodules listed in the .gitmodules of the repo's worktree, and their
+// submodules in turn, each as its own repo. Leaks are reported with the repo's name and their file is
+// prefixed with the submodule's path, like vendor/lib/config.py. Submodules that have not been
+// initialized and updated, so their history is not in the repo, are skipped with a warning.
+func (repo *Repo) AuditSubmodules() error {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	submodules, err := wt.Submodules()
+	if err != nil {
+		return err
+	}
+	for _, submodule := range submodules {
+		cfg := submodule.Config()
+		subPath := path.Join(repo.submodule, cfg.Path)
+
+		// submodule.Repository would init an empty repo for a submodule that was never updated
+		storer, err := repo.Storer.Module(cfg.Name)
+		if err != nil {
+			return err
+		}
+		if _, err := storer.Reference(plumbing.HEAD); err != nil {
+			log.Warnf("submodule %s is not initialized, skipping", subPath)
+			continue
+		}
+		worktree, err := wt.Filesystem.Chroot(cfg.Path)
+		if err != nil {
+			return err
+		}
+		repository, err := git.Open(storer, worktree)
+		if err != nil {
+			return err
+		}
+
+		sub := NewRepo(repo.Manager)
+		sub.Repository = repository
+		sub.Name = repo.Name
+		sub.submodule = subPath
+		if err := sub.Audit(); err != nil {
+			return err
+		}
+		if err := sub.AuditSubmodules(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// leakFile returns the file leaks in filename are reported with. Files in submodules are prefixed
+// with the submodule's path.
+func (repo *Repo) leakFile(filename string) string {
+	if repo.submodule == "" {
+		return filename
+	}
+	return path.Join(repo.submodule, filename)
+}
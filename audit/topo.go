@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"io"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+// topoOrder is used when --traversal-order=topo is set. It collects the commits of cIter, stopping
+// at commitTo if set, and returns an iterator over them in topological order where every commit comes
+// after its parents. Unlike date order this is not affected by skewed commit dates, so the commit that
+// introduced a change is always audited before the commits built on it. Every commit is held in
+// memory, and --depth limits the audit to the oldest commits rather than the newest.
+func topoOrder(cIter object.CommitIter, commitTo string) (object.CommitIter, error) {
+	var commits []*object.Commit
+	err := cIter.ForEach(func(c *object.Commit) error {
+		if c.Hash.String() == commitTo {
+			return storer.ErrStop
+		}
+		commits = append(commits, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byHash := make(map[plumbing.Hash]*object.Commit, len(commits))
+	for _, c := range commits {
+		byHash[c.Hash] = c
+	}
+
+	// iterative depth first search emitting each commit once all of its parents have been emitted
+	type frame struct {
+		commit  *object.Commit
+		visited bool
+	}
+	sorted := make([]*object.Commit, 0, len(commits))
+	done := make(map[plumbing.Hash]bool, len(commits))
+	for _, c := range commits {
+		stack := []frame{{commit: c}}
+		for len(stack) != 0 {
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if done[f.commit.Hash] {
+				continue
+			}
+			if f.visited {
+				done[f.commit.Hash] = true
+				sorted = append(sorted, f.commit)
+				continue
+			}
+			stack = append(stack, frame{commit: f.commit, visited: true})
+			for _, h := range f.commit.ParentHashes {
+				if p, ok := byHash[h]; ok && !done[h] {
+					stack = append(stack, frame{commit: p})
+				}
+			}
+		}
+	}
+	return &commitSliceIter{commits: sorted}, nil
+}
+
+// commitSliceIter is an object.CommitIter over a slice of commits
+type commitSliceIter struct {
+	commits []*object.Commit
+	pos     int
+}
+
+func (iter *commitSliceIter) Next() (*object.Commit, error) {
+	if iter.pos >= len(iter.commits) {
+		return nil, io.EOF
+	}
+	c := iter.commits[iter.pos]
+	iter.pos++
+	return c, nil
+}
+
+func (iter *commitSliceIter) ForEach(cb func(*object.Commit) error) error {
+	for {
+		c, err := iter.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err := cb(c); err == storer.ErrStop {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (iter *commitSliceIter) Close() {}
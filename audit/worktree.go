@@ -0,0 +1,117 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zricethezav/gitleaks/v3/manager"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/utils/binary"
+)
+
+// AuditWorkTree audits the files tracked in the repo's index as they are on disk when --work-tree is
+// set. It is a quick check of a checkout without walking its history. Untracked files and tracked
+// files deleted from disk are skipped. Leaks have no commit and are reported with the file's path in
+// the repo.
+func (repo *Repo) AuditWorkTree() error {
+	if repo.Manager.Opts.RepoConfig {
+		cfg, err := repo.loadRepoConfig()
+		if err != nil {
+			return err
+		}
+		repo.config = cfg
+	}
+	if err := repo.loadIgnore(); err != nil {
+		return err
+	}
+
+	if err := repo.setupTimeout(); err != nil {
+		return err
+	}
+	if repo.cancel != nil {
+		defer repo.cancel()
+	}
+
+	auditTimeStart := time.Now()
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+
+	// files on disk have no commit details
+	c := &object.Commit{}
+	c.Author.When = time.Unix(0, 0).UTC()
+
+	// unresolved merge conflicts have an entry per stage for the same file
+	audited := make(map[string]bool)
+	for _, e := range idx.Entries {
+		if repo.timeoutReached() {
+			break
+		}
+		if e.Mode == filemode.Submodule || e.Mode == filemode.Symlink || audited[e.Name] {
+			continue
+		}
+		audited[e.Name] = true
+
+		if repo.pathExcluded(e.Name) {
+			continue
+		}
+		if repo.fileWhitelisted(e.Name) {
+			log.Debugf("whitelisted file found, skipping audit of file: %s", e.Name)
+			continue
+		}
+		if repo.ignored(c, e.Name) {
+			continue
+		}
+		if fileMatched(e.Name, repo.config.FileRegex) {
+			repo.Manager.SendLeaks(manager.Leak{
+				Line:     "N/A",
+				Offender: e.Name,
+				Repo:     repo.Name,
+				Rule:     "file regex matched" + repo.config.FileRegex.String(),
+				Date:     c.Author.When,
+				File:     e.Name,
+			})
+		}
+
+		info, err := wt.Filesystem.Stat(e.Name)
+		if os.IsNotExist(err) {
+			log.Debugf("%s is tracked but not on disk, skipping", e.Name)
+			continue
+		} else if err != nil {
+			return err
+		}
+		if repo.tooLarge(e.Name, info.Size()) {
+			continue
+		}
+		f, err := wt.Filesystem.Open(e.Name)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		content, encoding := decodeContent(b, repo.Manager.Opts.DefaultEncoding)
+		if bin, _ := binary.IsBinary(strings.NewReader(content)); bin && !repo.Manager.Opts.ScanBinary {
+			continue
+		}
+		repo.encoding = encoding
+		inspectString(content, 1, c, repo, e.Name)
+	}
+	repo.encoding = ""
+
+	repo.Manager.RecordTime(manager.AuditTime(howLong(auditTimeStart)))
+	return nil
+}
@@ -1,22 +1,66 @@
 package config
 
 import (
+	"bufio"
+	"encoding/hex"
 	"fmt"
+	"os"
+	"path"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/zricethezav/gitleaks/v3/options"
 
 	"github.com/BurntSushi/toml"
 )
 
+var offenderHashRe = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// whitelistedCommitRe matches full and abbreviated commit shas
+var whitelistedCommitRe = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+
+// Severities are the severity levels a rule can be assigned, from least to most severe
+var Severities = []string{"low", "medium", "high", "critical"}
+
+// DefaultSeverity is the severity of rules that do not set one
+const DefaultSeverity = "medium"
+
+// EntropyAlgorithms are the algorithms a rule's entropies can be computed with, by the highest score
+// each gives. shannon is the Shannon entropy in bits per character and chisquare is how uniformly
+// characters are spread over their alphabet by a chi-square test, from 0 to 1.
+var EntropyAlgorithms = map[string]float64{
+	"shannon":   8.0,
+	"chisquare": 1.0,
+}
+
+// DefaultEntropyAlgorithm is the entropy algorithm of rules that do not set one
+const DefaultEntropyAlgorithm = "shannon"
+
+// SeverityRank returns the position of severity in Severities, so higher ranks are more severe.
+// -1 is returned if severity is not a severity level.
+func SeverityRank(severity string) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
 // Whitelist is struct containing items that if encountered will whitelist
 // a commit/line of code that would be considered a leak.
 type Whitelist struct {
 	Description string
 	Regex       *regexp.Regexp
 	File        *regexp.Regexp
+	// Commits are the commits, full or abbreviated shas, the rule's leaks are whitelisted in
+	Commits []string
+	// Expires is when the whitelist stops suppressing leaks. It is zero for whitelists that do not
+	// expire.
+	Expires time.Time
 }
 
 // entropy represents an entropy range
@@ -24,17 +68,136 @@ type entropy struct {
 	P1, P2 float64
 }
 
+// groupEntropy is an entropy range computed on a capture group of a rule's regex rather than on
+// the whole line, so a high entropy token in low entropy text can still trip it
+type groupEntropy struct {
+	P1, P2 float64
+	Group  int
+}
+
+// tomlEntropy is an entry of a rule's entropies. It is either a "min-max" string or a table with
+// min, max, and an optional group, the index of the capture group of the rule's regex to compute
+// entropy on.
+type tomlEntropy struct {
+	span     string
+	min, max float64
+	group    int
+	table    bool
+}
+
+// UnmarshalTOML implements toml.Unmarshaler so entropies can be written as strings or tables
+func (e *tomlEntropy) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case string:
+		e.span = v
+		return nil
+	case map[string]interface{}:
+		e.table = true
+		for key, value := range v {
+			var n float64
+			switch value := value.(type) {
+			case int64:
+				n = float64(value)
+			case float64:
+				n = value
+			default:
+				return fmt.Errorf("entropy %s must be a number", key)
+			}
+			switch strings.ToLower(key) {
+			case "min":
+				e.min = n
+			case "max":
+				e.max = n
+			case "group":
+				if n != float64(int(n)) {
+					return fmt.Errorf("entropy group must be an integer")
+				}
+				e.group = int(n)
+			default:
+				return fmt.Errorf("unknown entropy key %s, must be min, max, or group", key)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("entropies must be \"min-max\" strings or tables with min, max, and group")
+}
+
+// tomlExpiry is the expires date of a rule whitelist. It is either a toml datetime or a string in
+// RFC3339 or 2006-01-02 format. Dates without a time expire at the start of the day, local time.
+type tomlExpiry struct {
+	date time.Time
+}
+
+// UnmarshalTOML implements toml.Unmarshaler so expiry dates can be written as datetimes or strings
+func (e *tomlExpiry) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case time.Time:
+		e.date = v
+		return nil
+	case string:
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+				e.date = t
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("whitelist expires must be a date like 2006-01-02 or 2006-01-02T15:04:05Z07:00, got %v", data)
+}
+
 // Rule is a struct that contains information that is loaded from a gitleaks config.
 // This struct is used in the Config struct as an array of Rules and is iterated
 // over during an audit. Each rule will be checked. If a regex match is found AND
 // that match is not whitelisted (globally or locally), then a leak will be appended
 // to the final audit report.
+// Rules are compiled once when the config is loaded and are shared read-only across all
+// repo audits and goroutines. A compiled *regexp.Regexp is safe for concurrent use so
+// no copying or locking is required.
 type Rule struct {
 	Description string
 	Regex       *regexp.Regexp
 	Tags        []string
 	Whitelist   []Whitelist
-	Entropy     []entropy
+	// ExpiredWhitelist are the rule's whitelists that expired before the config was loaded. They
+	// are kept out of Whitelist so the leaks they suppressed are reported again.
+	ExpiredWhitelist []Whitelist
+	Entropy          []entropy
+	// GroupEntropy are the entropy ranges computed on capture groups of Regex
+	GroupEntropy []groupEntropy
+	Remediation  string
+	Severity     string
+	// MinDistinctChars is the minimum number of distinct characters an entropy finding must
+	// contain to be reported. Zero disables the check.
+	MinDistinctChars int
+	// EntropyAlgorithm is the algorithm Entropy and GroupEntropy ranges are computed with, one of
+	// EntropyAlgorithms
+	EntropyAlgorithm string
+	// Validate is the probe used to check if the rule's secrets are active when --validate is set.
+	// It is nil if the rule has no [rules.validate] table.
+	Validate *Validation
+	// AllowedExtensions are the file extensions, like .py, the rule is evaluated on. Empty means every
+	// extension. SkipExtensions are extensions the rule is not evaluated on. Both are lowercase with a
+	// leading dot.
+	AllowedExtensions []string
+	SkipExtensions    []string
+	// CoOccurrence must also match a line for the rule's leaks on it to be reported, like a keyword
+	// that gives a bare token meaning. It is nil if the rule has no co_occurrence.
+	CoOccurrence *regexp.Regexp
+	// Source is the config file the rule was loaded from. It is empty for the default config's rules.
+	Source string
+	// Multiline is set for rules whose matches span lines, like private key blocks. Leaks of multiline
+	// rules are reported with the first line of the match as their offender and line.
+	Multiline bool
+	// Disabled is set for rules with enabled = false. Disabled rules are removed by FilterRules
+	// unless they are enabled with --enable-rules.
+	Disabled bool
+}
+
+// KnownSecret is a secret loaded from a --known-secrets file. Either Value is set to the
+// plaintext secret or Hash is set to the secret's sha256 digest.
+type KnownSecret struct {
+	Value string
+	Hash  []byte
 }
 
 // Config is a composite struct of Rules and Whitelists
@@ -42,38 +205,80 @@ type Rule struct {
 type Config struct {
 	FileRegex *regexp.Regexp
 	Message   *regexp.Regexp
-	Rules     []Rule
-	Whitelist struct {
-		Description string
-		Commits     []string
-		File        *regexp.Regexp
+	// PathRegex is set by --path-regex and limits audits to the files whose path matches it. Files
+	// that do not match are not audited at all, unlike whitelisted files. It is nil if not set.
+	PathRegex    *regexp.Regexp
+	Rules        []Rule
+	KnownSecrets []KnownSecret
+	Whitelist    struct {
+		Description    string
+		Commits        []string
+		File           *regexp.Regexp
+		FileGlobs      Globs
+		OffenderHashes []string
+		Regexes        []*regexp.Regexp
+		// FileSizeLimit is the size in bytes above which files are not audited. Zero is unlimited.
+		FileSizeLimit int64
+		// EntropyPatterns suppress leaks found by entropy that they match, like UUIDs or commit shas.
+		// Leaks found by regex alone are not affected.
+		EntropyPatterns []*regexp.Regexp
 	}
+
+	// Ignore is loaded from the .gitleaksignore of each repo audited
+	Ignore Ignore
 }
 
 // TomlLoader gets loaded with the values from a gitleaks toml config
 // see the config in config/defaults.go for an example. TomlLoader is used
 // to generate Config values (compiling regexes, etc).
 type TomlLoader struct {
-	Global struct {
+	Title string
+	// Extends is the path of a config this config is merged over, relative to this config's directory
+	Extends string
+	Global  struct {
 		File    string
 		Message string
 	}
 	Whitelist struct {
-		Description string
-		Commits     []string
-		File        string
+		Description     string
+		Commits         []string
+		File            string
+		FileGlobs       []string `toml:"file_globs"`
+		OffenderHashes  []string
+		Regexes         []string
+		FileSizeLimit   int64    `toml:"file_size_limit"`
+		EntropyPatterns []string `toml:"entropy_whitelist_patterns"`
 	}
 	Rules []struct {
-		Description string
-		Regex       string
-		Tags        []string
-		Entropies   []string
-		Whitelist   []struct {
+		Description       string
+		Regex             string
+		CoOccurrence      string   `toml:"co_occurrence"`
+		AllowedExtensions []string `toml:"allowed_extensions"`
+		SkipExtensions    []string `toml:"skip_extensions"`
+		Flags             []string
+		Tags              []string
+		Entropies         []tomlEntropy
+		EntropyAlgorithm  string `toml:"entropy_algorithm"`
+		MinDistinctChars  int
+		Remediation       string
+		Severity          string
+		Multiline         bool
+		Enabled           *bool
+		Validate          *tomlValidation
+		// Source is the config file the rule was loaded from
+		Source    string `toml:"-"`
+		Whitelist []struct {
 			Description string
 			Regex       string
 			File        string
+			Commits     []string
+			Expires     tomlExpiry
 		}
 	}
+
+	// SeverityOverrides maps rule descriptions to the severity their leaks are reported with instead of
+	// the rule's own
+	SeverityOverrides map[string]string `toml:"severity_overrides"`
 }
 
 // NewConfig will create a new config struct which contains
@@ -86,7 +291,21 @@ func NewConfig(options options.Options) (Config, error) {
 
 	var err error
 	if options.Config != "" {
-		_, err = toml.DecodeFile(options.Config, &tomlLoader)
+		// configs are layered in order, each merged over the ones before it
+		for i, path := range strings.Split(options.Config, ",") {
+			path = strings.TrimSpace(path)
+			if i == 0 {
+				err = decodeFile(path, &tomlLoader)
+			} else {
+				layer := TomlLoader{}
+				if err = decodeFile(path, &layer); err == nil {
+					tomlLoader.merge(layer)
+				}
+			}
+			if err != nil {
+				return cfg, err
+			}
+		}
 	} else {
 		_, err = toml.Decode(DefaultConfig, &tomlLoader)
 	}
@@ -99,21 +318,236 @@ func NewConfig(options options.Options) (Config, error) {
 		return cfg, err
 	}
 
+	if unknown := cfg.FilterRules(options.EnableRules, options.DisableRules); len(unknown) != 0 {
+		return cfg, fmt.Errorf("problem loading config: no rule is described as %s", strings.Join(unknown, ", "))
+	}
+	cfg.FilterTags(options.IncludeTags, options.ExcludeTags)
+
+	if options.PathRegex != "" {
+		cfg.PathRegex, err = regexp.Compile(options.PathRegex)
+		if err != nil {
+			return cfg, fmt.Errorf("problem loading config: invalid path-regex %s: %v", options.PathRegex, err)
+		}
+	}
+
+	if options.KnownSecretsFile != "" {
+		cfg.KnownSecrets, err = loadKnownSecrets(options.KnownSecretsFile)
+		if err != nil {
+			return cfg, err
+		}
+	}
+
 	return cfg, nil
 }
 
+// FilterRules removes rules that should not be evaluated because they are disabled. Rules disabled in
+// the config with enabled = false are kept if they are named by enable, and rules named by disable are
+// removed. enable and disable are comma separated rule descriptions. The names that do not describe any
+// rule are returned so typos do not go unnoticed.
+func (cfg *Config) FilterRules(enable, disable string) []string {
+	enableRules, disableRules := splitRules(enable), splitRules(disable)
+	described := make(map[string]bool)
+	var rules []Rule
+	for _, rule := range cfg.Rules {
+		described[rule.Description] = true
+		if (rule.Disabled && !enableRules[rule.Description]) || disableRules[rule.Description] {
+			continue
+		}
+		rule.Disabled = false
+		rules = append(rules, rule)
+	}
+	cfg.Rules = rules
+
+	var unknown []string
+	for _, names := range []string{enable, disable} {
+		for name := range splitRules(names) {
+			if !described[name] {
+				unknown = append(unknown, name)
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+func splitRules(rules string) map[string]bool {
+	split := make(map[string]bool)
+	for _, rule := range strings.Split(rules, ",") {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			split[rule] = true
+		}
+	}
+	return split
+}
+
+// FilterTags removes rules that should not be evaluated based on their tags. include and exclude are
+// comma separated tags, matched case-insensitively. If include is set only rules with one of its tags
+// are kept, then rules with one of exclude's tags are removed.
+func (cfg *Config) FilterTags(include, exclude string) {
+	if include == "" && exclude == "" {
+		return
+	}
+	includeTags, excludeTags := splitTags(include), splitTags(exclude)
+	var rules []Rule
+	for _, rule := range cfg.Rules {
+		if len(includeTags) != 0 && !hasTag(rule, includeTags) {
+			continue
+		}
+		if hasTag(rule, excludeTags) {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	cfg.Rules = rules
+}
+
+func splitTags(tags string) map[string]bool {
+	split := make(map[string]bool)
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			split[tag] = true
+		}
+	}
+	return split
+}
+
+func hasTag(rule Rule, tags map[string]bool) bool {
+	for _, tag := range rule.Tags {
+		if tags[strings.ToLower(tag)] {
+			return true
+		}
+	}
+	return false
+}
+
+// loadKnownSecrets reads a file of known secrets, one per line. Lines prefixed with "sha256:" are
+// treated as hex encoded sha256 digests of a secret rather than the secret itself. Blank lines and
+// lines starting with # are ignored.
+func loadKnownSecrets(path string) ([]KnownSecret, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var secrets []KnownSecret
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "sha256:") {
+			digest := strings.ToLower(strings.TrimPrefix(line, "sha256:"))
+			if !offenderHashRe.MatchString(digest) {
+				return nil, fmt.Errorf("problem loading known secrets: invalid sha256 digest %q", digest)
+			}
+			hash, _ := hex.DecodeString(digest)
+			secrets = append(secrets, KnownSecret{Hash: hash})
+			continue
+		}
+		secrets = append(secrets, KnownSecret{Value: line})
+	}
+	return secrets, scanner.Err()
+}
+
+// merge layers the config loaded into layer over tomlLoader. Rules are identified by their description,
+// a rule in layer replaces the rule with the same description and other rules are appended. Whitelists
+// are unioned, whitelisted file regexes are combined so a file matching either is whitelisted. Global
+// regexes, descriptions, the file size limit, and severity overrides set in layer replace the earlier
+// ones.
+func (tomlLoader *TomlLoader) merge(layer TomlLoader) {
+	index := make(map[string]int)
+	for i, rule := range tomlLoader.Rules {
+		index[rule.Description] = i
+	}
+	for _, rule := range layer.Rules {
+		// rules without a description can not be overridden
+		if i, ok := index[rule.Description]; ok && rule.Description != "" {
+			tomlLoader.Rules[i] = rule
+			continue
+		}
+		tomlLoader.Rules = append(tomlLoader.Rules, rule)
+	}
+
+	if layer.Global.File != "" {
+		tomlLoader.Global.File = layer.Global.File
+	}
+	if layer.Global.Message != "" {
+		tomlLoader.Global.Message = layer.Global.Message
+	}
+	for description, severity := range layer.SeverityOverrides {
+		if tomlLoader.SeverityOverrides == nil {
+			tomlLoader.SeverityOverrides = make(map[string]string)
+		}
+		tomlLoader.SeverityOverrides[description] = severity
+	}
+
+	if layer.Whitelist.Description != "" {
+		tomlLoader.Whitelist.Description = layer.Whitelist.Description
+	}
+	switch {
+	case tomlLoader.Whitelist.File == "":
+		tomlLoader.Whitelist.File = layer.Whitelist.File
+	case layer.Whitelist.File != "":
+		tomlLoader.Whitelist.File = fmt.Sprintf("(?:%s)|(?:%s)", tomlLoader.Whitelist.File, layer.Whitelist.File)
+	}
+	tomlLoader.Whitelist.FileGlobs = append(tomlLoader.Whitelist.FileGlobs, layer.Whitelist.FileGlobs...)
+	tomlLoader.Whitelist.Commits = append(tomlLoader.Whitelist.Commits, layer.Whitelist.Commits...)
+	tomlLoader.Whitelist.OffenderHashes = append(tomlLoader.Whitelist.OffenderHashes, layer.Whitelist.OffenderHashes...)
+	tomlLoader.Whitelist.Regexes = append(tomlLoader.Whitelist.Regexes, layer.Whitelist.Regexes...)
+	tomlLoader.Whitelist.EntropyPatterns = append(tomlLoader.Whitelist.EntropyPatterns, layer.Whitelist.EntropyPatterns...)
+	if layer.Whitelist.FileSizeLimit != 0 {
+		tomlLoader.Whitelist.FileSizeLimit = layer.Whitelist.FileSizeLimit
+	}
+}
+
 // Parse will parse the values set in a TomlLoader and use those values
 // to create compiled regular expressions and rules used in audits
 func (tomlLoader TomlLoader) Parse() (Config, error) {
 	var cfg Config
-	for _, rule := range tomlLoader.Rules {
-		re, err := regexp.Compile(rule.Regex)
+	// configs passed to --config have been merged over the configs they extend by now, repo configs
+	// are not read from disk so they can not extend other configs
+	if tomlLoader.Extends != "" {
+		return cfg, fmt.Errorf("problem loading config: extends %s, only configs passed to --config can extend other configs", tomlLoader.Extends)
+	}
+	now := time.Now()
+	for i, rule := range tomlLoader.Rules {
+		if rule.Regex == "" && len(rule.Entropies) == 0 {
+			return cfg, fmt.Errorf("problem loading config: rule %s must set a regex or entropies", ruleName(i, rule.Description))
+		}
+		for _, tag := range rule.Tags {
+			if strings.TrimSpace(tag) == "" || strings.Contains(tag, ",") {
+				return cfg, fmt.Errorf("problem loading config: rule %s has invalid tag %q, tags must not be empty or contain commas",
+					ruleName(i, rule.Description), tag)
+			}
+		}
+		flags, err := regexFlags(rule.Description, rule.Flags)
+		if err != nil {
+			return cfg, err
+		}
+		pattern := rule.Regex
+		// entropy only rules have no regex and must keep an empty one
+		if pattern != "" && flags != "" {
+			pattern = "(?" + flags + ")" + pattern
+		}
+		re, err := regexp.Compile(pattern)
 		if err != nil {
 			return cfg, fmt.Errorf("problem loading config: %v", err)
 		}
+		var coOccurrence *regexp.Regexp
+		if rule.CoOccurrence != "" {
+			pattern := rule.CoOccurrence
+			if flags != "" {
+				pattern = "(?" + flags + ")" + pattern
+			}
+			if coOccurrence, err = regexp.Compile(pattern); err != nil {
+				return cfg, fmt.Errorf("problem loading config: rule %s has invalid co_occurrence: %v", rule.Description, err)
+			}
+		}
 
 		// rule specific whitelists
-		var whitelists []Whitelist
+		var whitelists, expired []Whitelist
 		for _, wl := range rule.Whitelist {
 			re, err := regexp.Compile(wl.Regex)
 			if err != nil {
@@ -123,27 +557,112 @@ func (tomlLoader TomlLoader) Parse() (Config, error) {
 			if err != nil {
 				return cfg, fmt.Errorf("problem loading config: %v", err)
 			}
-			whitelists = append(whitelists, Whitelist{
+			var commits []string
+			for _, commit := range wl.Commits {
+				commit = strings.ToLower(strings.TrimSpace(commit))
+				if !whitelistedCommitRe.MatchString(commit) {
+					return cfg, fmt.Errorf("problem loading config: rule %s whitelisted commit %s must be a sha of 4 to 40 hex characters", rule.Description, commit)
+				}
+				commits = append(commits, commit)
+			}
+			whitelist := Whitelist{
 				Description: wl.Description,
 				File:        fileRe,
 				Regex:       re,
-			})
+				Commits:     commits,
+				Expires:     wl.Expires.date,
+			}
+			if !whitelist.Expires.IsZero() && !now.Before(whitelist.Expires) {
+				expired = append(expired, whitelist)
+				continue
+			}
+			whitelists = append(whitelists, whitelist)
 		}
 
-		entropies, err := getEntropy(rule.Entropies)
+		algorithm := strings.ToLower(rule.EntropyAlgorithm)
+		if algorithm == "" {
+			algorithm = DefaultEntropyAlgorithm
+		}
+		if _, ok := EntropyAlgorithms[algorithm]; !ok {
+			return cfg, fmt.Errorf("problem loading config: rule %s has invalid entropy_algorithm %s, must be chisquare or shannon",
+				rule.Description, rule.EntropyAlgorithm)
+		}
+		entropies, groupEntropies, err := getEntropy(rule.Entropies, re, EntropyAlgorithms[algorithm])
+		if err != nil {
+			return cfg, err
+		}
+		if rule.Multiline && len(rule.Entropies) != 0 {
+			return cfg, fmt.Errorf("problem loading config: rule %s can not be multiline and have entropies, entropies are checked line by line",
+				ruleName(i, rule.Description))
+		}
+		if rule.MinDistinctChars < 0 {
+			return cfg, fmt.Errorf("problem loading config: minDistinctChars must not be negative")
+		}
+		severity := strings.ToLower(rule.Severity)
+		if severity == "" {
+			severity = DefaultSeverity
+		}
+		if SeverityRank(severity) == -1 {
+			return cfg, fmt.Errorf("problem loading config: rule %s has invalid severity %s, must be one of %s",
+				rule.Description, rule.Severity, strings.Join(Severities, ", "))
+		}
+
+		description := rule.Description
+		if description == "" {
+			description = defaultDescription(re, entropies, groupEntropies)
+		}
+		validation, err := parseValidation(description, rule.Validate)
 		if err != nil {
 			return cfg, err
 		}
 
 		cfg.Rules = append(cfg.Rules, Rule{
-			Description: rule.Description,
-			Regex:       re,
-			Tags:        rule.Tags,
-			Whitelist:   whitelists,
-			Entropy:     entropies,
+			Description:       description,
+			Regex:             re,
+			Tags:              rule.Tags,
+			Whitelist:         whitelists,
+			ExpiredWhitelist:  expired,
+			Entropy:           entropies,
+			GroupEntropy:      groupEntropies,
+			Remediation:       rule.Remediation,
+			Severity:          severity,
+			MinDistinctChars:  rule.MinDistinctChars,
+			EntropyAlgorithm:  algorithm,
+			Validate:          validation,
+			CoOccurrence:      coOccurrence,
+			AllowedExtensions: extensions(rule.AllowedExtensions),
+			SkipExtensions:    extensions(rule.SkipExtensions),
+			Source:            rule.Source,
+			Multiline:         rule.Multiline,
+			Disabled:          rule.Enabled != nil && !*rule.Enabled,
 		})
 	}
 
+	// severity overrides replace the severity of rules by description so a shared ruleset can be
+	// re-weighted by a small config layered over it or a repo config
+	var overridden []string
+	for description := range tomlLoader.SeverityOverrides {
+		overridden = append(overridden, description)
+	}
+	sort.Strings(overridden)
+	for _, description := range overridden {
+		severity := strings.ToLower(tomlLoader.SeverityOverrides[description])
+		if SeverityRank(severity) == -1 {
+			return cfg, fmt.Errorf("problem loading config: severity_overrides sets rule %s to invalid severity %s, must be one of %s",
+				description, tomlLoader.SeverityOverrides[description], strings.Join(Severities, ", "))
+		}
+		found := false
+		for i := range cfg.Rules {
+			if cfg.Rules[i].Description == description {
+				cfg.Rules[i].Severity = severity
+				found = true
+			}
+		}
+		if !found {
+			return cfg, fmt.Errorf("problem loading config: severity_overrides names rule %s, which is not in the config", description)
+		}
+	}
+
 	// global leaks
 	if tomlLoader.Global.File != "" {
 		re, err := regexp.Compile(tomlLoader.Global.File)
@@ -168,33 +687,172 @@ func (tomlLoader TomlLoader) Parse() (Config, error) {
 		}
 		cfg.Whitelist.File = re
 	}
-	cfg.Whitelist.Commits = tomlLoader.Whitelist.Commits
+	// file globs are easier to get right for paths than file regexes, like vendor/** or **/*.lock
+	for _, glob := range tomlLoader.Whitelist.FileGlobs {
+		re, err := compileGlob(glob)
+		if err != nil {
+			return cfg, fmt.Errorf("problem loading config: invalid whitelist file glob %s: %v", glob, err)
+		}
+		cfg.Whitelist.FileGlobs = append(cfg.Whitelist.FileGlobs, re)
+	}
+	// whitelisted commits may be abbreviated, like the short shas git log --oneline shows
+	for _, commit := range tomlLoader.Whitelist.Commits {
+		commit = strings.ToLower(strings.TrimSpace(commit))
+		if !whitelistedCommitRe.MatchString(commit) {
+			return cfg, fmt.Errorf("problem loading config: whitelisted commit %s must be a sha of 4 to 40 hex characters", commit)
+		}
+		cfg.Whitelist.Commits = append(cfg.Whitelist.Commits, commit)
+	}
 	cfg.Whitelist.Description = tomlLoader.Whitelist.Description
 
-	return cfg, nil
-}
+	// offender hashes are sha256 hex digests of offenders that should never be reported.
+	// Storing hashes lets users whitelist known values without committing the plaintext.
+	for _, h := range tomlLoader.Whitelist.OffenderHashes {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if !offenderHashRe.MatchString(h) {
+			return cfg, fmt.Errorf("problem loading config: invalid offender hash %q, must be a sha256 hex digest", h)
+		}
+		cfg.Whitelist.OffenderHashes = append(cfg.Whitelist.OffenderHashes, h)
+	}
 
-// getEntropy
-func getEntropy(entropyStr []string) ([]entropy, error) {
-	var ranges []entropy
-	for _, span := range entropyStr {
-		split := strings.Split(span, "-")
-		v1, err := strconv.ParseFloat(split[0], 64)
+	// whitelisted regexes suppress any offender they match, whichever rule found it
+	for _, r := range tomlLoader.Whitelist.Regexes {
+		re, err := regexp.Compile(r)
 		if err != nil {
-			return nil, err
+			return cfg, fmt.Errorf("problem loading config: invalid whitelist regex %s: %v", r, err)
 		}
-		v2, err := strconv.ParseFloat(split[1], 64)
+		cfg.Whitelist.Regexes = append(cfg.Whitelist.Regexes, re)
+	}
+
+	// entropy whitelist patterns suppress the high entropy values that are not secrets, like uuids
+	for _, r := range tomlLoader.Whitelist.EntropyPatterns {
+		re, err := regexp.Compile(r)
 		if err != nil {
-			return nil, err
+			return cfg, fmt.Errorf("problem loading config: invalid entropy whitelist pattern %s: %v", r, err)
+		}
+		cfg.Whitelist.EntropyPatterns = append(cfg.Whitelist.EntropyPatterns, re)
+	}
+
+	// large files, like minified or generated code, are slow to audit and prone to false positives
+	if tomlLoader.Whitelist.FileSizeLimit < 0 {
+		return cfg, fmt.Errorf("problem loading config: file_size_limit must not be negative")
+	}
+	cfg.Whitelist.FileSizeLimit = tomlLoader.Whitelist.FileSizeLimit
+
+	return cfg, nil
+}
+
+// extensions normalizes the extensions of a rule's allowed_extensions or skip_extensions, so py, .py,
+// and .PY are all .py
+func extensions(exts []string) []string {
+	var normalized []string
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized = append(normalized, ext)
+	}
+	return normalized
+}
+
+// AppliesTo returns true if the rule is evaluated on filename given its allowed and skipped
+// extensions. Content that is not a file, like a commit message, has no filename and every rule
+// applies to it.
+func (rule Rule) AppliesTo(filename string) bool {
+	if filename == "" {
+		return true
+	}
+	ext := strings.ToLower(path.Ext(filename))
+	if len(rule.AllowedExtensions) != 0 && !containsString(rule.AllowedExtensions, ext) {
+		return false
+	}
+	return !containsString(rule.SkipExtensions, ext)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// regexFlags checks the flags set on a rule's regex and returns them as inline regex flags, like im.
+// i is case-insensitive, m makes ^ and $ match at line breaks, and s lets . match line breaks.
+func regexFlags(rule string, flags []string) (string, error) {
+	var inline string
+	for _, flag := range flags {
+		switch flag {
+		case "i", "m", "s":
+			if !strings.Contains(inline, flag) {
+				inline += flag
+			}
+		default:
+			return "", fmt.Errorf("problem loading config: rule %s has unknown regex flag %q, must be i, m, or s", rule, flag)
+		}
+	}
+	return inline, nil
+}
+
+// defaultDescription describes a rule without a description by its regex and entropy ranges. Leaks are
+// reported with their rule's description so every rule needs one, including entropy only rules.
+func defaultDescription(re *regexp.Regexp, entropies []entropy, groupEntropies []groupEntropy) string {
+	var ranges []string
+	for _, e := range entropies {
+		ranges = append(ranges, fmt.Sprintf("%g-%g", e.P1, e.P2))
+	}
+	for _, e := range groupEntropies {
+		ranges = append(ranges, fmt.Sprintf("%g-%g on group %d", e.P1, e.P2, e.Group))
+	}
+	switch {
+	case re.String() == "":
+		return "entropy " + strings.Join(ranges, ", ")
+	case len(ranges) == 0:
+		return "regex " + re.String()
+	}
+	return fmt.Sprintf("regex %s with entropy %s", re, strings.Join(ranges, ", "))
+}
+
+// getEntropy parses a rule's entropies, which must be within 0 and max, the highest score of the
+// rule's entropy algorithm. Ranges with a group are returned separately and must name a capture
+// group of the rule's regex re.
+func getEntropy(entropies []tomlEntropy, re *regexp.Regexp, max float64) ([]entropy, []groupEntropy, error) {
+	var (
+		ranges      []entropy
+		groupRanges []groupEntropy
+	)
+	for _, e := range entropies {
+		if !e.table {
+			split := strings.Split(e.span, "-")
+			v1, err := strconv.ParseFloat(split[0], 64)
+			if err != nil {
+				return nil, nil, err
+			}
+			v2, err := strconv.ParseFloat(split[1], 64)
+			if err != nil {
+				return nil, nil, err
+			}
+			e.min, e.max = v1, v2
+		}
+		if e.min > e.max {
+			return nil, nil, fmt.Errorf("entropy range must be ascending")
+		}
+		if e.min > max || e.min < 0.0 || e.max > max || e.max < 0.0 {
+			return nil, nil, fmt.Errorf("invalid entropy ranges, must be within 0.0-%.1f", max)
 		}
-		if v1 > v2 {
-			return nil, fmt.Errorf("entropy range must be ascending")
+		if e.group == 0 {
+			ranges = append(ranges, entropy{P1: e.min, P2: e.max})
+			continue
 		}
-		r := entropy{P1: v1, P2: v2}
-		if r.P1 > 8.0 || r.P1 < 0.0 || r.P2 > 8.0 || r.P2 < 0.0 {
-			return nil, fmt.Errorf("invalid entropy ranges, must be within 0.0-8.0")
+		if e.group < 0 || re.String() == "" || e.group > re.NumSubexp() {
+			return nil, nil, fmt.Errorf("entropy group %d is not a capture group of the rule's regex", e.group)
 		}
-		ranges = append(ranges, r)
+		groupRanges = append(groupRanges, groupEntropy{P1: e.min, P2: e.max, Group: e.group})
 	}
-	return ranges, nil
+	return ranges, groupRanges, nil
 }
@@ -10,127 +10,164 @@ title = "gitleaks config"
 	description = "AWS Manager ID"
 	regex = '''(A3T[A-Z0-9]|AKIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA|ASIA)[A-Z0-9]{16}'''
 	tags = ["key", "AWS"]
+	remediation = "Deactivate and delete the access key in AWS IAM, then issue a new key"
 
 [[rules]]
 	description = "AWS Secret Key"
 	regex = '''(?i)aws(.{0,20})?(?-i)['\"][0-9a-zA-Z\/+]{40}['\"]'''
 	tags = ["key", "AWS"]
+	remediation = "Deactivate and delete the access key in AWS IAM, then issue a new key"
 
 [[rules]]
 	description = "AWS MWS key"
 	regex = '''amzn\.mws\.[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}'''
 	tags = ["key", "AWS", "MWS"]
+	remediation = "Regenerate the MWS auth token in Amazon Seller Central"
 
 [[rules]]
 	description = "Facebook Secret Key"
 	regex = '''(?i)(facebook|fb)(.{0,20})?(?-i)['\"][0-9a-f]{32}['\"]'''
 	tags = ["key", "Facebook"]
+	remediation = "Reset the app secret in the Facebook developer dashboard"
 
 [[rules]]
 	description = "Facebook Client ID"
 	regex = '''(?i)(facebook|fb)(.{0,20})?['\"][0-9]{13,17}['\"]'''
 	tags = ["key", "Facebook"]
+	remediation = "Review the app in the Facebook developer dashboard and reset its secret if it was also exposed"
 
 [[rules]]
 	description = "Twitter Secret Key"
 	regex = '''(?i)twitter(.{0,20})?['\"][0-9a-z]{35,44}['\"]'''
 	tags = ["key", "Twitter"]
+	remediation = "Regenerate the consumer keys and access tokens in the Twitter developer portal"
 
 [[rules]]
 	description = "Twitter Client ID"
 	regex = '''(?i)twitter(.{0,20})?['\"][0-9a-z]{18,25}['\"]'''
 	tags = ["client", "Twitter"]
+	remediation = "Regenerate the consumer keys in the Twitter developer portal"
 
 [[rules]]
 	description = "Github"
 	regex = '''(?i)github(.{0,20})?(?-i)['\"][0-9a-zA-Z]{35,40}['\"]'''
 	tags = ["key", "Github"]
+	remediation = "Revoke the token in GitHub developer settings and create a new one"
 
 [[rules]]
 	description = "LinkedIn Client ID"
 	regex = '''(?i)linkedin(.{0,20})?(?-i)['\"][0-9a-z]{12}['\"]'''
 	tags = ["client", "LinkedIn"]
+	remediation = "Review the app in the LinkedIn developer portal and reset its secret if it was also exposed"
 
 [[rules]]
 	description = "LinkedIn Secret Key"
 	regex = '''(?i)linkedin(.{0,20})?['\"][0-9a-z]{16}['\"]'''
 	tags = ["secret", "LinkedIn"]
+	remediation = "Reset the client secret in the LinkedIn developer portal"
 
 [[rules]]
 	description = "Slack"
 	regex = '''xox[baprs]-([0-9a-zA-Z]{10,48})?'''
 	tags = ["key", "Slack"]
+	remediation = "Revoke the token in the Slack app management page and issue a new one"
 
 [[rules]]
 	description = "Asymmetric Private Key"
 	regex = '''-----BEGIN ((EC|PGP|DSA|RSA|OPENSSH) )?PRIVATE KEY( BLOCK)?-----'''
 	tags = ["key", "AsymmetricPrivateKey"]
+	remediation = "Revoke and replace the key pair and remove the public key from anywhere it is trusted"
 
 [[rules]]
 	description = "Generic Credential"
 	regex = '''(?i)(api_key|apikey|secret)(.{0,20})?['|"][0-9a-zA-Z]{16,45}['|"]'''
 	tags = ["key", "API", "generic"]
+	remediation = "Rotate the credential with the service that issued it"
 
 [[rules]]
 	description = "Google API key"
 	regex = '''AIza[0-9A-Za-z\\-_]{35}'''
 	tags = ["key", "Google"]
+	remediation = "Delete or regenerate the key in the Google Cloud console credentials page"
 
 
 [[rules]]
 	description = "Heroku API key"
 	regex = '''(?i)heroku(.{0,20})?['"][0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}['"]'''
 	tags = ["key", "Heroku"]
+	remediation = "Regenerate the API key in Heroku account settings"
 
 [[rules]]
 	description = "MailChimp API key"
 	regex = '''(?i)(mailchimp|mc)(.{0,20})?['"][0-9a-f]{32}-us[0-9]{1,2}['"]'''
 	tags = ["key", "Mailchimp"]
+	remediation = "Disable the key in MailChimp account extras and create a new one"
 
 [[rules]]
 	description = "Mailgun API key"
 	regex = '''(?i)(mailgun|mg)(.{0,20})?['"][0-9a-z]{32}['"]'''
 	tags = ["key", "Mailgun"]
+	remediation = "Reset the API key in the Mailgun dashboard security settings"
 
 [[rules]]
 	description = "PayPal Braintree access token"
 	regex = '''access_token\$production\$[0-9a-z]{16}\$[0-9a-f]{32}'''
 	tags = ["key", "Paypal"]
+	remediation = "Revoke the access token in the Braintree control panel"
 
 [[rules]]
 	description = "Picatic API key"
 	regex = '''sk_live_[0-9a-z]{32}'''
 	tags = ["key", "Picatic"]
+	remediation = "Regenerate the API key in the Picatic account settings"
 
 [[rules]]
 	description = "SendGrid API Key"
 	regex = '''SG\.[\w_]{16,32}\.[\w_]{16,64}'''
 	tags = ["key", "SendGrid"]
+	remediation = "Delete the API key in SendGrid settings and create a new one"
 
 [[rules]]
 	description = "Slack Webhook"
 	regex = '''https://hooks.slack.com/services/T[a-zA-Z0-9_]{8}/B[a-zA-Z0-9_]{8}/[a-zA-Z0-9_]{24}'''
 	tags = ["key", "slack"]
+	remediation = "Regenerate the webhook URL in the Slack app's incoming webhook settings"
 
 [[rules]]
 	description = "Stripe API key"
 	regex = '''(?i)stripe(.{0,20})?['\"][sk|rk]_live_[0-9a-zA-Z]{24}'''
 	tags = ["key", "Stripe"]
+	remediation = "Roll the API key in the Stripe dashboard"
 
 [[rules]]
 	description = "Square access token"
 	regex = '''sq0atp-[0-9A-Za-z\-_]{22}'''
 	tags = ["key", "square"]
+	remediation = "Revoke the access token in the Square developer dashboard"
 
 [[rules]]
 	description = "Square OAuth secret"
 	regex = '''sq0csp-[0-9A-Za-z\\-_]{43}'''
 	tags = ["key", "square"]
+	remediation = "Replace the application secret in the Square developer dashboard"
 
 [[rules]]
 	description = "Twilio API key"
 	regex = '''(?i)twilio(.{0,20})?['\"][0-9a-f]{32}['\"]'''
 	tags = ["key", "twilio"]
+	remediation = "Delete the API key in the Twilio console and create a new one"
+
+[[rules]]
+	description = "Connection String"
+	regex = '''(?i)\b(jdbc:)?(postgres|postgresql|mysql|mariadb|mongodb|mongodb\+srv|redis|rediss|amqps?|mssql|sqlserver)://[^\s'"<>@/:]+:[^\s'"<>@]*@[^\s'"<>/?;]+'''
+	tags = ["password", "connection-string"]
+	remediation = "Change the database or service password and load it from the environment or a secret store"
+
+[[rules]]
+	description = "JDBC Connection String"
+	regex = '''(?i)\bjdbc:[a-z0-9]+://[^\s'"<>]*password=[^\s'"<>;&]*'''
+	tags = ["password", "connection-string", "JDBC"]
+	remediation = "Change the database password and load it from the environment or a secret store"
 
 [whitelist]
 	description = "Whitelisted files"
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+
+	"github.com/BurntSushi/toml"
+)
+
+// envVarRe matches the ${VAR} and ${VAR:-default} placeholders of a config file, and $${ which is a
+// literal ${
+var envVarRe = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\}`)
+
+// decodeFile decodes the config file at path into tomlLoader after expanding its placeholders, and
+// records path as the source of its rules. Keys that are not config settings are an error. If the
+// file extends another config, that config is loaded first and the file is merged over it. Only
+// config files passed to --config are expanded. Repo configs are written by whoever can push to the
+// repo audited, so expanding them could leak the environment into reports through rule descriptions.
+func decodeFile(path string, tomlLoader *TomlLoader) error {
+	return decodeExtended(path, tomlLoader, nil)
+}
+
+// decodeExtended is decodeFile for a config extended by the configs in chain, the first of which
+// was passed to --config
+func decodeExtended(path string, tomlLoader *TomlLoader, chain []string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	data, err := expandEnv(string(b))
+	if err != nil {
+		return fmt.Errorf("problem loading config %s: %v", path, err)
+	}
+	md, err := toml.Decode(data, tomlLoader)
+	if err != nil {
+		return err
+	}
+	if err = checkKeys(md, data); err != nil {
+		return fmt.Errorf("problem loading config %s: %v", path, err)
+	}
+	for i := range tomlLoader.Rules {
+		tomlLoader.Rules[i].Source = path
+	}
+	return tomlLoader.extend(path, chain)
+}
+
+// expandEnv replaces each ${VAR} in data with the value of the environment variable VAR, which
+// must be set. ${VAR:-default} is replaced with default if VAR is unset or empty, like in a shell.
+// This keeps values like internal hostnames out of configs committed to git.
+func expandEnv(data string) (string, error) {
+	var err error
+	expanded := envVarRe.ReplaceAllStringFunc(data, func(placeholder string) string {
+		if placeholder == "$${" {
+			return "${"
+		}
+		m := envVarRe.FindStringSubmatch(placeholder)
+		name, fallback := m[1], m[2]
+		value, ok := os.LookupEnv(name)
+		switch {
+		case fallback != "" && value == "":
+			return fallback[len(":-"):]
+		case !ok && err == nil:
+			err = fmt.Errorf("environment variable %s is not set, use ${%s:-default} for a default", name, name)
+		}
+		return value
+	})
+	return expanded, err
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// extend loads the config that tomlLoader, loaded from path, extends and merges tomlLoader over it,
+// the way configs passed to --config are layered. The extended config's path is relative to the
+// directory of path. chain is the configs that extend path, used to stop configs that extend each
+// other from being loaded forever.
+func (tomlLoader *TomlLoader) extend(path string, chain []string) error {
+	if tomlLoader.Extends == "" {
+		return nil
+	}
+	// urls are not fetched, configs are only read from disk for now
+	if u, err := url.Parse(tomlLoader.Extends); err == nil && len(u.Scheme) > 1 {
+		return fmt.Errorf("problem loading config %s: extends %s, only local configs can be extended", path, tomlLoader.Extends)
+	}
+	extended := tomlLoader.Extends
+	if !filepath.IsAbs(extended) {
+		extended = filepath.Join(filepath.Dir(path), extended)
+	}
+
+	chain = append(chain, path)
+	for _, p := range chain {
+		if samePath(p, extended) {
+			return fmt.Errorf("problem loading config %s: extends cycle %s -> %s", chain[0], strings.Join(chain, " -> "), extended)
+		}
+	}
+
+	var base TomlLoader
+	if err := decodeExtended(extended, &base, chain); err != nil {
+		return err
+	}
+	base.merge(*tomlLoader)
+	if tomlLoader.Title != "" {
+		base.Title = tomlLoader.Title
+	}
+	base.Extends = ""
+	*tomlLoader = base
+	return nil
+}
+
+// samePath reports whether the paths a and b name the same file
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Globs are whitelisted file globs compiled to regexes. Globs are matched against a file's whole
+// path from the repo root. * and ? match within a directory, ** matches any number of directories,
+// and [...] matches a character class like it does in path.Match.
+type Globs []*regexp.Regexp
+
+// Match returns true if file matches one of the globs. Backslashes in file are treated as path
+// separators so paths from Windows, or archives built on Windows, match the same globs.
+func (globs Globs) Match(file string) bool {
+	file = strings.TrimPrefix(strings.Replace(file, `\`, "/", -1), "./")
+	for _, re := range globs {
+		if re.MatchString(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileGlob compiles glob to a regex matching the paths the glob matches
+func compileGlob(glob string) (*regexp.Regexp, error) {
+	glob = strings.TrimPrefix(strings.Replace(glob, `\`, "/", -1), "/")
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if !strings.HasPrefix(glob[i:], "**") {
+				b.WriteString("[^/]*")
+				continue
+			}
+			// ** is only special as a whole path segment, otherwise it is like *
+			segment := i == 0 || glob[i-1] == '/'
+			i++
+			switch {
+			case segment && strings.HasPrefix(glob[i+1:], "/"):
+				// **/ matches no directories or any number of them
+				b.WriteString("(?:.*/)?")
+				i++
+			case segment && i == len(glob)-1:
+				// a trailing ** matches everything in the directory
+				b.WriteString(".*")
+			default:
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile is the name of the file at the root of a repo listing files and commit:path pairs
+// that should not be audited
+const IgnoreFile = ".gitleaksignore"
+
+// ignoreLocationRe matches a commit:path line of an ignore file
+var ignoreLocationRe = regexp.MustCompile(`^([0-9a-fA-F]{40}):(.+)$`)
+
+// Ignore holds the suppressions listed in a repo's .gitleaksignore. These let teams manage
+// suppressions in their repo through code review rather than in a central config.
+type Ignore struct {
+	// Globs are matched like .gitignore patterns. A glob without a slash matches a file's base
+	// name or any directory it is in, otherwise it is matched against the path from the repo root.
+	Globs []string
+
+	// Locations are commit:path pairs, the file at path is only ignored in that commit
+	Locations map[string]bool
+}
+
+// ParseIgnore parses an ignore file read from r. Each line is either a file glob or a commit:path
+// pair. Blank lines and lines starting with # are skipped. Globs are validated so a bad pattern
+// errors rather than silently matching nothing.
+func ParseIgnore(r io.Reader) (Ignore, error) {
+	ignore := Ignore{Locations: make(map[string]bool)}
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if m := ignoreLocationRe.FindStringSubmatch(line); m != nil {
+			ignore.Locations[ignoreLocation(strings.ToLower(m[1]), m[2])] = true
+			continue
+		}
+		if _, err := path.Match(line, ""); err != nil {
+			return ignore, fmt.Errorf("problem loading %s: line %d has invalid glob %s", IgnoreFile, lineNumber, line)
+		}
+		ignore.Globs = append(ignore.Globs, strings.TrimPrefix(line, "/"))
+	}
+	if err := scanner.Err(); err != nil {
+		return ignore, fmt.Errorf("problem loading %s: %v", IgnoreFile, err)
+	}
+	return ignore, nil
+}
+
+// Ignored returns true if file in commit matches a glob or commit:path pair of the ignore file
+func (ignore Ignore) Ignored(commit, file string) bool {
+	if ignore.Locations[ignoreLocation(commit, file)] {
+		return true
+	}
+	for _, glob := range ignore.Globs {
+		if !strings.Contains(glob, "/") {
+			// match the base name and each directory the file is in
+			for _, name := range strings.Split(file, "/") {
+				if ok, _ := path.Match(glob, name); ok {
+					return true
+				}
+			}
+			continue
+		}
+		// match the path and each directory the file is in
+		for p := file; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+			if ok, _ := path.Match(glob, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func ignoreLocation(commit, file string) string {
+	return commit + ":" + file
+}
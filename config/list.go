@@ -0,0 +1,38 @@
+package config
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ruleListing is a rule as listed by --list-rules. Rules are identified by their description.
+type ruleListing struct {
+	Description string   `json:"description"`
+	Severity    string   `json:"severity"`
+	Tags        []string `json:"tags"`
+	Entropy     bool     `json:"entropy"`
+	Source      string   `json:"source,omitempty"`
+}
+
+// WriteRules writes the rules of cfg as a json list, in the order they are evaluated, so the rules
+// left after configs are layered and rules are filtered can be checked before an audit. Each rule's
+// source is the config file it was loaded from, or omitted for the default config.
+func (cfg Config) WriteRules(w io.Writer) error {
+	rules := []ruleListing{}
+	for _, rule := range cfg.Rules {
+		tags := rule.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		rules = append(rules, ruleListing{
+			Description: rule.Description,
+			Severity:    rule.Severity,
+			Tags:        tags,
+			Entropy:     len(rule.Entropy) != 0 || len(rule.GroupEntropy) != 0,
+			Source:      rule.Source,
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", " ")
+	return encoder.Encode(rules)
+}
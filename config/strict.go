@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// checkKeys returns an error naming the first key of a config file that is not a config setting,
+// like regexp misspelled for regex. Misspelled settings are otherwise dropped and the config
+// silently matches less than it was written to. Keys of a rule are reported with the rule's
+// description, or its position in the file if it has none.
+func checkKeys(md toml.MetaData, data string) error {
+	for _, key := range md.Undecoded() {
+		// tables of entropies are decoded by tomlEntropy, which checks their keys itself
+		if len(key) > 2 && key[0] == "rules" && key[1] == "entropies" {
+			continue
+		}
+		if len(key) < 2 || key[0] != "rules" {
+			return fmt.Errorf("unknown key %s", key)
+		}
+		var raw struct {
+			Rules []map[string]interface{}
+		}
+		if _, err := toml.Decode(data, &raw); err != nil {
+			return err
+		}
+		for i, rule := range raw.Rules {
+			if hasKey(rule, key[1:]) {
+				description, _ := rule["description"].(string)
+				return fmt.Errorf("rule %s has unknown key %s", ruleName(i, description), strings.Join(key[1:], "."))
+			}
+		}
+		return fmt.Errorf("unknown key %s", key)
+	}
+	return nil
+}
+
+// hasKey reports whether the decoded toml value v, a table or an array of tables, sets the key at
+// path
+func hasKey(v interface{}, path []string) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		value, ok := v[path[0]]
+		if !ok {
+			return false
+		}
+		return len(path) == 1 || hasKey(value, path[1:])
+	case []map[string]interface{}:
+		for _, table := range v {
+			if hasKey(table, path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ruleName names the i-th rule of a config in errors by its description, or by its position if it
+// has none
+func ruleName(i int, description string) string {
+	if description != "" {
+		return description
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
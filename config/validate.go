@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SecretPlaceholder is replaced with a leak's secret in the url and headers of a rule's validate probe
+const SecretPlaceholder = "{{secret}}"
+
+// Validation is a rule's validate probe. When --validate is set the probe's url is requested for each
+// secret the rule finds and the response's status code tells whether the secret is active.
+type Validation struct {
+	URL     string
+	Method  string
+	Headers map[string]string
+	// Active are the status codes returned for active secrets and Inactive for revoked or invalid
+	// secrets. Other status codes, and failed requests, leave the secret's status unknown.
+	Active   []int
+	Inactive []int
+}
+
+// tomlValidation is a rule's [rules.validate] table
+type tomlValidation struct {
+	URL      string
+	Method   string
+	Headers  map[string]string
+	Active   []int
+	Inactive []int
+}
+
+// parseValidation checks a rule's validate table and fills in its defaults. Probes are GET requests
+// and 200 means active and 401 or 403 inactive unless the table says otherwise.
+func parseValidation(rule string, v *tomlValidation) (*Validation, error) {
+	if v == nil {
+		return nil, nil
+	}
+	u, err := url.Parse(strings.Replace(v.URL, SecretPlaceholder, "secret", -1))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("problem loading config: rule %s validate url %q must be an http or https url", rule, v.URL)
+	}
+	placeholder := strings.Contains(v.URL, SecretPlaceholder)
+	for _, value := range v.Headers {
+		placeholder = placeholder || strings.Contains(value, SecretPlaceholder)
+	}
+	if !placeholder {
+		return nil, fmt.Errorf("problem loading config: rule %s validate must use %s in its url or headers", rule, SecretPlaceholder)
+	}
+
+	validation := &Validation{
+		URL:      v.URL,
+		Method:   strings.ToUpper(v.Method),
+		Headers:  v.Headers,
+		Active:   v.Active,
+		Inactive: v.Inactive,
+	}
+	if validation.Method == "" {
+		validation.Method = http.MethodGet
+	}
+	if len(validation.Active) == 0 {
+		validation.Active = []int{http.StatusOK}
+	}
+	if len(validation.Inactive) == 0 {
+		validation.Inactive = []int{http.StatusUnauthorized, http.StatusForbidden}
+	}
+	return validation, nil
+}
@@ -0,0 +1,220 @@
+package hosts
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/zricethezav/gitleaks/v3/audit"
+	"github.com/zricethezav/gitleaks/v3/manager"
+	"github.com/zricethezav/gitleaks/v3/options"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4"
+	githttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+)
+
+// bitbucketPageLimit is how many repos are requested per page. Bitbucket Server caps the limit at
+// its configured maximum, 1000 by default.
+const bitbucketPageLimit = 100
+
+// Bitbucket wraps a Bitbucket Server REST API client and manager. This struct implements what the Host
+// interface defines.
+type Bitbucket struct {
+	client  *http.Client
+	manager *manager.Manager
+
+	// apiURL is the url of the REST API, --bitbucket-url joined with --bitbucket-api-path
+	apiURL string
+}
+
+// bitbucketRepo is a repo as listed by Bitbucket Server. Forks have an origin, the repo they were
+// forked from.
+type bitbucketRepo struct {
+	Slug    string `json:"slug"`
+	Name    string `json:"name"`
+	Project struct {
+		Key string `json:"key"`
+	} `json:"project"`
+	Origin *struct {
+		Slug string `json:"slug"`
+	} `json:"origin"`
+	Links struct {
+		Clone []struct {
+			Href string `json:"href"`
+			Name string `json:"name"`
+		} `json:"clone"`
+	} `json:"links"`
+}
+
+// bitbucketPage is a page of a Bitbucket Server paged API response
+type bitbucketPage struct {
+	Values        []bitbucketRepo `json:"values"`
+	Start         int             `json:"start"`
+	Limit         int             `json:"limit"`
+	IsLastPage    bool            `json:"isLastPage"`
+	NextPageStart int             `json:"nextPageStart"`
+}
+
+// NewBitbucketClient accepts a manager struct and returns a Bitbucket host pointer which will be used to
+// perform a Bitbucket Server audit on a project. --bitbucket-url, or --baseurl with --host=bitbucket, is
+// the server's url and --bitbucket-api-path is where its REST API is served under that url.
+func NewBitbucketClient(m *manager.Manager) (*Bitbucket, error) {
+	baseURL := m.Opts.BitbucketURL
+	if baseURL == "" {
+		baseURL = m.Opts.BaseURL
+	}
+	if baseURL == "" {
+		return nil, fmt.Errorf("bitbucket audits require bitbucket-url to be set")
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bitbucket url %s: %v", baseURL, err)
+	}
+	apiPath := m.Opts.BitbucketAPIPath
+	if apiPath == "" {
+		apiPath = "/rest/api/1.0"
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.Trim(apiPath, "/")
+
+	return &Bitbucket{
+		client:  http.DefaultClient,
+		manager: m,
+		apiURL:  u.String(),
+	}, nil
+}
+
+// Audit will audit the repos of a Bitbucket Server project. Leaks from every repo are collected by the
+// manager into a single report. Repos are named by their project and slug, like PROJ/api.
+func (b *Bitbucket) Audit() {
+	repos, err := b.listRepos()
+	if err != nil {
+		log.Warnf("unable to list all bitbucket repos, auditing the %d repos listed: %v", len(repos), err)
+	}
+
+	for _, repo := range repos {
+		cloneURL := repo.cloneURL()
+		if cloneURL == "" {
+			log.Warnf("bitbucket repo %s/%s has no http clone url, skipping", repo.Project.Key, repo.Slug)
+			continue
+		}
+		r := audit.NewRepo(b.manager)
+		cloneOptions := &git.CloneOptions{URL: cloneURL}
+		if token := b.token(); token != "" {
+			if b.manager.Opts.Username != "" {
+				cloneOptions.Auth = &githttp.BasicAuth{
+					Username: b.manager.Opts.Username,
+					Password: token,
+				}
+			} else {
+				// http access tokens are accepted as bearer tokens for git operations too
+				cloneOptions.Auth = &githttp.TokenAuth{Token: token}
+			}
+		} else if b.manager.CloneOptions != nil {
+			cloneOptions.Auth = b.manager.CloneOptions.Auth
+		}
+		if err := r.Clone(cloneOptions); err != nil {
+			log.Warnf("err cloning %s, skipping clone and audit: %v", cloneURL, err)
+			continue
+		}
+		r.Name = repo.Project.Key + "/" + repo.Slug
+
+		if err = r.Audit(); err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+// project returns the key of the project being audited, set by --bitbucket-project or --org
+func (b *Bitbucket) project() string {
+	if b.manager.Opts.BitbucketProject != "" {
+		return b.manager.Opts.BitbucketProject
+	}
+	return b.manager.Opts.Organization
+}
+
+// listRepos pages through the repos of the project being audited. Forks are left out if
+// --exclude-forks is set.
+func (b *Bitbucket) listRepos() ([]bitbucketRepo, error) {
+	var repos []bitbucketRepo
+	start := 0
+	for {
+		page, err := b.listReposPage(start)
+		if err != nil {
+			return repos, err
+		}
+		for _, repo := range page.Values {
+			if b.manager.Opts.ExcludeForks && repo.Origin != nil {
+				log.Debugf("excluding forked repo: %s", repo.Slug)
+				continue
+			}
+			repos = append(repos, repo)
+		}
+		if page.IsLastPage || len(page.Values) == 0 {
+			return repos, nil
+		}
+		// older servers leave out nextPageStart
+		next := page.NextPageStart
+		if next <= start {
+			next = page.Start + len(page.Values)
+		}
+		start = next
+	}
+}
+
+// listReposPage requests the page of the project's repos beginning at start
+func (b *Bitbucket) listReposPage(start int) (*bitbucketPage, error) {
+	query := url.Values{}
+	query.Set("start", strconv.Itoa(start))
+	query.Set("limit", strconv.Itoa(bitbucketPageLimit))
+	reqURL := fmt.Sprintf("%s/projects/%s/repos?%s", b.apiURL, url.PathEscape(b.project()), query.Encode())
+
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if token := b.token(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bitbucket responded %s listing the repos of project %s", resp.Status, b.project())
+	}
+
+	var page bitbucketPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("problem decoding bitbucket repos: %v", err)
+	}
+	return &page, nil
+}
+
+// cloneURL returns the repo's http clone url, or an empty string if it has none
+func (repo bitbucketRepo) cloneURL() string {
+	for _, link := range repo.Links.Clone {
+		if link.Name == "http" || link.Name == "https" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// token returns the access token used to list and clone bitbucket repos
+func (b *Bitbucket) token() string {
+	if b.manager.Opts.BitbucketToken != "" {
+		return b.manager.Opts.BitbucketToken
+	}
+	return options.GetAccessToken(b.manager.Opts)
+}
+
+// AuditPR TODO not implemented
+func (b *Bitbucket) AuditPR() {
+	log.Error("AuditPR is not implemented in Bitbucket host yet...")
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/zricethezav/gitleaks/v3/audit"
 	"github.com/zricethezav/gitleaks/v3/manager"
@@ -16,8 +17,12 @@ import (
 	"gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/plumbing"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
 )
 
+// sleep is used to back off from github's rate limit
+var sleep = time.Sleep
+
 // Github wraps a github client and manager. This struct implements what the Host interface defines.
 type Github struct {
 	client  *github.Client
@@ -30,8 +35,9 @@ type Github struct {
 func NewGithubClient(m *manager.Manager) (*Github, error) {
 	var err error
 	ctx := context.Background()
+	g := &Github{manager: m}
 	token := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: options.GetAccessToken(m.Opts)},
+		&oauth2.Token{AccessToken: g.token()},
 	)
 
 	var githubClient *github.Client
@@ -43,22 +49,66 @@ func NewGithubClient(m *manager.Manager) (*Github, error) {
 		githubClient, err = github.NewEnterpriseClient(m.Opts.BaseURL, m.Opts.BaseURL, httpClient)
 	}
 
-	return &Github{
-		manager: m,
-		client:  githubClient,
-	}, err
+	g.client = githubClient
+	return g, err
 }
 
-// Audit will audit a github user or organization's repos.
+// Audit will audit a github user or organization's repos. Leaks from every repo are collected
+// by the manager into a single report.
 func (g *Github) Audit() {
+	githubRepos, err := g.listRepos()
+	if err != nil {
+		log.Warnf("unable to list all github repos, auditing the %d repos listed: %v", len(githubRepos), err)
+	}
+
+	for _, repo := range githubRepos {
+		r := audit.NewRepo(g.manager)
+		r.Name = *repo.Name
+		// private repos can only be cloned with the token they were listed with
+		cloneOptions := &git.CloneOptions{URL: *repo.CloneURL}
+		if token := g.token(); token != "" {
+			cloneOptions.Auth = &http.BasicAuth{
+				Username: "gitleaks_user",
+				Password: token,
+			}
+		}
+		err := r.Clone(cloneOptions)
+		if err != nil {
+			log.Warn("unable to clone via https and access token, attempting with ssh now")
+			auth, err := options.SSHAuth(g.manager.Opts)
+			if err != nil {
+				log.Warnf("unable to get ssh auth, skipping clone and audit for repo %s: %+v\n", *repo.CloneURL, err)
+			}
+			err = r.Clone(&git.CloneOptions{
+				URL:  *repo.SSHURL,
+				Auth: auth,
+			})
+			if err != nil {
+				log.Warnf("err cloning %s, skipping clone and audit: %+v\n", *repo.SSHURL, err)
+				continue
+			}
+		}
+		if err = r.Audit(); err != nil {
+			log.Warn(err)
+		}
+	}
+}
+
+// listRepos pages through the repos of the github user or organization being audited. Forks are
+// left out if --exclude-forks is set. Organization repos include private repos the token has access
+// to. When the rate limit is reached listing waits for the limit to reset rather than failing.
+func (g *Github) listRepos() ([]*github.Repository, error) {
 	ctx := context.Background()
 	listOptions := github.ListOptions{
 		PerPage: 100,
 		Page:    1,
 	}
+	org := g.manager.Opts.Organization
+	if org == "" {
+		org = g.manager.Opts.GithubOrg
+	}
 
 	var githubRepos []*github.Repository
-
 	for {
 		var (
 			_githubRepos []*github.Repository
@@ -68,9 +118,16 @@ func (g *Github) Audit() {
 		if g.manager.Opts.User != "" {
 			_githubRepos, resp, err = g.client.Repositories.List(ctx, g.manager.Opts.User,
 				&github.RepositoryListOptions{ListOptions: listOptions})
-		} else if g.manager.Opts.Organization != "" {
-			_githubRepos, resp, err = g.client.Repositories.ListByOrg(ctx, g.manager.Opts.Organization,
-				&github.RepositoryListByOrgOptions{ListOptions: listOptions})
+		} else if org != "" {
+			_githubRepos, resp, err = g.client.Repositories.ListByOrg(ctx, org,
+				&github.RepositoryListByOrgOptions{Type: "all", ListOptions: listOptions})
+		}
+		if g.waitForRateLimit(resp, err) {
+			// retry the same page
+			continue
+		}
+		if err != nil {
+			return githubRepos, err
 		}
 
 		for _, r := range _githubRepos {
@@ -81,7 +138,6 @@ func (g *Github) Audit() {
 			githubRepos = append(githubRepos, r)
 		}
 
-
 		if resp == nil {
 			break
 		}
@@ -93,35 +149,46 @@ func (g *Github) Audit() {
 		}
 
 		listOptions.Page = resp.NextPage
-		if err != nil || listOptions.Page == 0 {
+		if listOptions.Page == 0 {
 			break
 		}
 	}
+	return githubRepos, nil
+}
 
-	for _, repo := range githubRepos {
-		r := audit.NewRepo(g.manager)
-		r.Name = *repo.Name
-		err := r.Clone(&git.CloneOptions{
-			URL: *repo.CloneURL,
-		})
-		if err != nil {
-			log.Warn("unable to clone via https and access token, attempting with ssh now")
-			auth, err := options.SSHAuth(g.manager.Opts)
-			if err != nil {
-				log.Warnf("unable to get ssh auth, skipping clone and audit for repo %s: %+v\n", *repo.CloneURL, err)
-			}
-			err = r.Clone(&git.CloneOptions{
-				URL:  *repo.SSHURL,
-				Auth: auth,
-			})
-			if err != nil {
-				log.Warnf("err cloning %s, skipping clone and audit: %+v\n", *repo.SSHURL, err)
-			}
-		}
-		if err = r.Audit(); err != nil {
-			log.Warn(err)
+// waitForRateLimit backs off until github's rate limit resets. It waits if the last request failed
+// because of the rate limit, in which case true is returned so the request can be retried, or if the
+// X-RateLimit-Remaining header of the last response shows no requests are left.
+func (g *Github) waitForRateLimit(resp *github.Response, err error) bool {
+	switch e := err.(type) {
+	case *github.RateLimitError:
+		wait := time.Until(e.Rate.Reset.Time)
+		log.Warnf("github rate limit reached, waiting %s for it to reset", wait.Round(time.Second))
+		sleep(wait)
+		return true
+	case *github.AbuseRateLimitError:
+		wait := time.Minute
+		if e.RetryAfter != nil {
+			wait = *e.RetryAfter
 		}
+		log.Warnf("github abuse rate limit reached, waiting %s to retry", wait.Round(time.Second))
+		sleep(wait)
+		return true
+	}
+	if err == nil && resp != nil && resp.Rate.Limit != 0 && resp.Rate.Remaining == 0 {
+		wait := time.Until(resp.Rate.Reset.Time)
+		log.Warnf("github rate limit reached, waiting %s for it to reset", wait.Round(time.Second))
+		sleep(wait)
+	}
+	return false
+}
+
+// token returns the access token used for github requests
+func (g *Github) token() string {
+	if g.manager.Opts.GithubToken != "" {
+		return g.manager.Opts.GithubToken
 	}
+	return options.GetAccessToken(g.manager.Opts)
 }
 
 // AuditPR audits a single github PR
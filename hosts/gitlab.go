@@ -2,6 +2,9 @@ package hosts
 
 import (
 	"context"
+	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/zricethezav/gitleaks/v3/audit"
@@ -10,6 +13,8 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/xanzy/go-gitlab"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
 )
 
 // Gitlab wraps a gitlab client and manager. This struct implements what the Host interface defines.
@@ -21,85 +26,199 @@ type Gitlab struct {
 }
 
 // NewGitlabClient accepts a manager struct and returns a Gitlab host pointer which will be used to
-// perform a gitlab audit on an group or user.
+// perform a gitlab audit on an group or user. Self hosted instances are set by --gitlab-url or --baseurl.
 func NewGitlabClient(m *manager.Manager) (*Gitlab, error) {
 	var err error
 
 	gitlabClient := &Gitlab{
 		manager: m,
 		ctx:     context.Background(),
-		client:  gitlab.NewClient(nil, options.GetAccessToken(m.Opts)),
 	}
+	gitlabClient.client = gitlab.NewClient(nil, gitlabClient.token())
 
-	if m.Opts.BaseURL != "" {
-		err = gitlabClient.client.SetBaseURL(m.Opts.BaseURL)
+	baseURL := m.Opts.GitlabURL
+	if baseURL == "" {
+		baseURL = m.Opts.BaseURL
+	}
+	if baseURL != "" {
+		err = gitlabClient.client.SetBaseURL(baseURL)
 	}
 
 	return gitlabClient, err
 }
 
-// Audit will audit a github user or organization's repos.
+// Audit will audit a gitlab user's or group's projects. Leaks from every project are collected by the
+// manager into a single report. Projects of a --gitlab-group audit are named by their full path, like
+// group/subgroup/project, so projects with the same name in different subgroups can be told apart.
 func (g *Gitlab) Audit() {
 	var (
 		projects []*gitlab.Project
-		resp     *gitlab.Response
 		err      error
 	)
-
-	page := 1
-	listOpts := gitlab.ListOptions{
-		PerPage: 100,
-		Page:    page,
+	if g.manager.Opts.GitlabGroup != "" {
+		projects, err = g.listGroupProjects(g.manager.Opts.GitlabGroup, 0)
+	} else {
+		projects, err = g.listProjects()
 	}
-	for {
-		var _projects []*gitlab.Project
-		if g.manager.Opts.User != "" {
-			glOpts := &gitlab.ListProjectsOptions{
-				ListOptions: listOpts,
+	if err != nil {
+		log.Warnf("unable to list all gitlab projects, auditing the %d projects listed: %v", len(projects), err)
+	}
+
+	// iterate of gitlab projects
+	for _, p := range projects {
+		r := audit.NewRepo(g.manager)
+		cloneOptions := &git.CloneOptions{URL: p.HTTPURLToRepo}
+		if token := g.token(); token != "" {
+			// private projects can only be cloned with the token they were listed with
+			cloneOptions.Auth = &http.BasicAuth{
+				Username: "oauth2",
+				Password: token,
 			}
-			_projects, resp, err = g.client.Projects.ListUserProjects(g.manager.Opts.User, glOpts)
+		} else if g.manager.CloneOptions != nil {
+			cloneOptions.Auth = g.manager.CloneOptions.Auth
+		}
+		// TODO handle clone retry with ssh like github host
+		if err := r.Clone(cloneOptions); err != nil {
+			log.Warnf("err cloning %s, skipping clone and audit: %v", p.HTTPURLToRepo, err)
+			continue
+		}
+		r.Name = p.Name
+		if g.manager.Opts.GitlabGroup != "" {
+			r.Name = p.PathWithNamespace
+		}
+
+		if err = r.Audit(); err != nil {
+			log.Error(err)
+		}
+	}
+}
 
+// listProjects pages through the projects of the gitlab user or group set by --user or --org. Forks
+// are left out if --exclude-forks is set.
+func (g *Gitlab) listProjects() ([]*gitlab.Project, error) {
+	var projects []*gitlab.Project
+	err := paginate(func(listOpts gitlab.ListOptions) (*gitlab.Response, error) {
+		var (
+			_projects []*gitlab.Project
+			resp      *gitlab.Response
+			err       error
+		)
+		if g.manager.Opts.User != "" {
+			_projects, resp, err = g.client.Projects.ListUserProjects(g.manager.Opts.User,
+				&gitlab.ListProjectsOptions{ListOptions: listOpts})
 		} else if g.manager.Opts.Organization != "" {
-			glOpts := &gitlab.ListGroupProjectsOptions{
-				ListOptions: listOpts,
-			}
-			_projects, resp, err = g.client.Groups.ListGroupProjects(g.manager.Opts.Organization, glOpts)
+			_projects, resp, err = g.client.Groups.ListGroupProjects(g.manager.Opts.Organization,
+				&gitlab.ListGroupProjectsOptions{ListOptions: listOpts})
 		}
+		projects = append(projects, g.withoutForks(_projects)...)
+		return resp, err
+	})
+	return projects, err
+}
+
+// listGroupProjects lists the projects of group and, recursively, of its subgroups. group is either a
+// group's full path or its id and depth is how many subgroups deep it is. Subgroups deeper than
+// --gitlab-depth are not listed.
+func (g *Gitlab) listGroupProjects(group interface{}, depth int) ([]*gitlab.Project, error) {
+	var projects []*gitlab.Project
+	err := paginate(func(listOpts gitlab.ListOptions) (*gitlab.Response, error) {
+		_projects, resp, err := g.client.Groups.ListGroupProjects(group,
+			&gitlab.ListGroupProjectsOptions{ListOptions: listOpts})
+		projects = append(projects, g.withoutForks(_projects)...)
+		return resp, err
+	})
+	if err != nil || depth >= g.manager.Opts.GitlabDepth {
+		return projects, err
+	}
+
+	var subgroups []*gitlab.Group
+	err = paginate(func(listOpts gitlab.ListOptions) (*gitlab.Response, error) {
+		_subgroups, resp, err := g.client.Groups.ListSubgroups(group,
+			&gitlab.ListSubgroupsOptions{ListOptions: listOpts})
+		subgroups = append(subgroups, _subgroups...)
+		return resp, err
+	})
+	if err != nil {
+		return projects, err
+	}
+	for _, subgroup := range subgroups {
+		_projects, err := g.listGroupProjects(subgroup.ID, depth+1)
+		projects = append(projects, _projects...)
 		if err != nil {
-			log.Error(err)
+			return projects, err
 		}
+	}
+	return projects, nil
+}
 
-		for _, p := range _projects {
-			if g.manager.Opts.ExcludeForks && p.ForkedFromProject != nil {
-				log.Debugf("excluding forked repo: %s", p.Name)
-				continue
-			}
-			projects = append(projects, p)
+// withoutForks returns projects without forks if --exclude-forks is set
+func (g *Gitlab) withoutForks(projects []*gitlab.Project) []*gitlab.Project {
+	if !g.manager.Opts.ExcludeForks {
+		return projects
+	}
+	var kept []*gitlab.Project
+	for _, p := range projects {
+		if p.ForkedFromProject != nil {
+			log.Debugf("excluding forked repo: %s", p.Name)
+			continue
 		}
+		kept = append(kept, p)
+	}
+	return kept
+}
 
-		if resp == nil {
-			break
+// token returns the access token used to list and clone gitlab projects
+func (g *Gitlab) token() string {
+	if g.manager.Opts.GitlabToken != "" {
+		return g.manager.Opts.GitlabToken
+	}
+	return options.GetAccessToken(g.manager.Opts)
+}
+
+// paginate calls list with each page of results, starting with the first, until there is no next page
+// or list returns an error
+func paginate(list func(gitlab.ListOptions) (*gitlab.Response, error)) error {
+	listOpts := gitlab.ListOptions{
+		PerPage: 100,
+		Page:    1,
+	}
+	for {
+		resp, err := list(listOpts)
+		if err != nil {
+			return err
 		}
-		if page >= resp.TotalPages {
+		next := nextPage(resp)
+		if next <= listOpts.Page {
 			// exit when we've seen all pages
-			break
+			return nil
 		}
-		page = resp.NextPage
+		listOpts.Page = next
 	}
+}
 
-	// iterate of gitlab projects
-	for _, p := range projects {
-		r := audit.NewRepo(g.manager)
-		cloneOpts := g.manager.CloneOptions
-		cloneOpts.URL = p.HTTPURLToRepo
-		err := r.Clone(cloneOpts)
-		// TODO handle clone retry with ssh like github host
-		r.Name = p.Name
-
-		if err = r.Audit(); err != nil {
-			log.Error(err)
+// nextPage returns the page after resp, or 0 if resp is the last page. Gitlab sends the next page in
+// the X-Next-Page header, which some instances and proxies leave out, so the page of the Link header's
+// next url is used when it is missing.
+func nextPage(resp *gitlab.Response) int {
+	if resp == nil || resp.Response == nil {
+		return 0
+	}
+	if resp.NextPage != 0 {
+		return resp.NextPage
+	}
+	for _, link := range strings.Split(resp.Header.Get("Link"), ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 || strings.TrimSpace(parts[1]) != `rel="next"` {
+			continue
+		}
+		u, err := url.Parse(strings.Trim(strings.TrimSpace(parts[0]), "<>"))
+		if err != nil {
+			return 0
 		}
+		page, _ := strconv.Atoi(u.Query().Get("page"))
+		return page
 	}
+	return 0
 }
 
 // AuditPR TODO not implemented
@@ -1,6 +1,7 @@
 package hosts
 
 import (
+	"github.com/zricethezav/gitleaks/v3/audit"
 	"github.com/zricethezav/gitleaks/v3/manager"
 	"strings"
 )
@@ -8,10 +9,11 @@ import (
 const (
 	_github int = iota + 1
 	_gitlab
+	_bitbucket
 )
 
-// Host is an interface used for defining external git hosting providers like github and gitlab.
-// TODO add bitbucket
+// Host is an interface used for defining external git hosting providers like github, gitlab, and
+// bitbucket.
 type Host interface {
 	Audit()
 	AuditPR()
@@ -19,23 +21,37 @@ type Host interface {
 
 // Run kicks off a host audit. This function accepts a manager and determines what host it should audit
 func Run(m *manager.Manager) error {
+	audit.WarnExpiredWhitelists(m.Config)
 	var host Host
 	var err error
-	switch getHost(m.Opts.Host) {
+	hostName := m.Opts.Host
+	if m.Opts.GithubOrg != "" {
+		hostName = "github"
+	} else if m.Opts.GitlabGroup != "" {
+		hostName = "gitlab"
+	} else if m.Opts.BitbucketProject != "" {
+		hostName = "bitbucket"
+	}
+	switch getHost(hostName) {
 	case _github:
 		host, err = NewGithubClient(m)
 	case _gitlab:
 		host, err = NewGitlabClient(m)
+	case _bitbucket:
+		host, err = NewBitbucketClient(m)
 	default:
 		return nil
 	}
+	if err != nil {
+		return err
+	}
 
 	if m.Opts.PullRequest != "" {
 		host.AuditPR()
 	} else {
 		host.Audit()
 	}
-	return err
+	return nil
 }
 
 func getHost(host string) int {
@@ -43,6 +59,8 @@ func getHost(host string) int {
 		return _github
 	} else if strings.ToLower(host) == "gitlab" {
 		return _gitlab
+	} else if strings.ToLower(host) == "bitbucket" {
+		return _bitbucket
 	}
 	return -1
 }
@@ -3,11 +3,18 @@ package hosts
 import (
 	"flag"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
 	"github.com/zricethezav/gitleaks/v3/config"
 	"github.com/zricethezav/gitleaks/v3/manager"
 	"github.com/zricethezav/gitleaks/v3/options"
-	"os"
-	"testing"
 )
 
 var (
@@ -113,3 +120,230 @@ func TestGitlab(t *testing.T) {
 		}
 	}
 }
+
+func TestGithubListReposRateLimit(t *testing.T) {
+	reset := time.Now().Add(2 * time.Second).Truncate(time.Second)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !strings.HasSuffix(r.URL.Path, "/orgs/gitleakstest/repos") {
+			t.Errorf("unexpected request for %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("type") != "all" {
+			t.Errorf("got repo type %q, wanted all", r.URL.Query().Get("type"))
+		}
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		switch r.URL.Query().Get("page") {
+		case "1":
+			// the last request before the limit is reached
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=2>; rel="next", <%s%s?page=2>; rel="last"`,
+				"http://"+r.Host, r.URL.Path, "http://"+r.Host, r.URL.Path))
+			fmt.Fprint(w, `[{"name": "gronit", "fork": false}, {"name": "forked", "fork": true}]`)
+		case "2":
+			w.Header().Set("X-RateLimit-Remaining", "59")
+			fmt.Fprint(w, `[{"name": "secret-project", "private": true, "fork": false}]`)
+		}
+	}))
+	defer server.Close()
+
+	var waited []time.Duration
+	sleep = func(d time.Duration) {
+		waited = append(waited, d)
+		time.Sleep(d)
+	}
+	defer func() { sleep = time.Sleep }()
+
+	opts := options.Options{
+		GithubOrg:    "gitleakstest",
+		GithubToken:  "token",
+		BaseURL:      server.URL,
+		ExcludeForks: true,
+	}
+	cfg, err := config.NewConfig(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := manager.NewManager(opts, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGithubClient(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos, err := g.listRepos()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, r := range repos {
+		names = append(names, r.GetName())
+	}
+	if !reflect.DeepEqual(names, []string{"gronit", "secret-project"}) {
+		t.Errorf("got repos %v, wanted [gronit secret-project]", names)
+	}
+	if requests != 2 {
+		t.Errorf("got %d requests, wanted 2", requests)
+	}
+	if len(waited) != 1 || waited[0] <= 0 {
+		t.Errorf("expected to wait for the rate limit to reset once, waited %v", waited)
+	}
+}
+
+func TestGitlabListGroupProjects(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path+"?page="+r.URL.Query().Get("page"))
+		if r.Header.Get("Private-Token") != "token" {
+			t.Errorf("got token %q, wanted token", r.Header.Get("Private-Token"))
+		}
+		switch r.URL.Path + "?page=" + r.URL.Query().Get("page") {
+		case "/gitlab/api/v4/groups/acme/projects?page=1":
+			// only a Link header, no X-Next-Page
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=2&per_page=100>; rel="next", <http://%s%s?page=2&per_page=100>; rel="last"`,
+				r.Host, r.URL.Path, r.Host, r.URL.Path))
+			fmt.Fprint(w, `[{"name": "api", "path_with_namespace": "acme/api"}, {"name": "fork", "path_with_namespace": "acme/fork", "forked_from_project": {"id": 1}}]`)
+		case "/gitlab/api/v4/groups/acme/projects?page=2":
+			fmt.Fprint(w, `[{"name": "web", "path_with_namespace": "acme/web"}]`)
+		case "/gitlab/api/v4/groups/acme/subgroups?page=1":
+			fmt.Fprint(w, `[{"id": 7, "full_path": "acme/platform"}]`)
+		case "/gitlab/api/v4/groups/7/projects?page=1":
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"name": "api", "path_with_namespace": "acme/platform/api"}]`)
+		case "/gitlab/api/v4/groups/7/projects?page=2":
+			fmt.Fprint(w, `[{"name": "db", "path_with_namespace": "acme/platform/db"}]`)
+		case "/gitlab/api/v4/groups/7/subgroups?page=1":
+			fmt.Fprint(w, `[{"id": 8, "full_path": "acme/platform/legacy"}]`)
+		case "/gitlab/api/v4/groups/8/projects?page=1":
+			fmt.Fprint(w, `[{"name": "old", "path_with_namespace": "acme/platform/legacy/old"}]`)
+		case "/gitlab/api/v4/groups/8/subgroups?page=1":
+			fmt.Fprint(w, `[]`)
+		default:
+			t.Errorf("unexpected request for %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		depth int
+		want  []string
+	}{
+		{
+			depth: 0,
+			want:  []string{"acme/api", "acme/web"},
+		},
+		{
+			depth: 1,
+			want:  []string{"acme/api", "acme/web", "acme/platform/api", "acme/platform/db"},
+		},
+		{
+			depth: 5,
+			want:  []string{"acme/api", "acme/web", "acme/platform/api", "acme/platform/db", "acme/platform/legacy/old"},
+		},
+	}
+	for _, test := range tests {
+		opts := options.Options{
+			GitlabGroup:  "acme",
+			GitlabToken:  "token",
+			GitlabURL:    server.URL + "/gitlab",
+			GitlabDepth:  test.depth,
+			ExcludeForks: true,
+		}
+		cfg, err := config.NewConfig(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := manager.NewManager(opts, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, err := NewGitlabClient(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		projects, err := g.listGroupProjects(opts.GitlabGroup, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, p := range projects {
+			got = append(got, p.PathWithNamespace)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("depth %d: got projects %v, wanted %v", test.depth, got, test.want)
+		}
+	}
+}
+
+func TestBitbucketListRepos(t *testing.T) {
+	var starts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bitbucket/proxy/rest/api/1.0/projects/ACME/repos" {
+			t.Errorf("unexpected request for %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("got authorization %q, wanted Bearer token", r.Header.Get("Authorization"))
+		}
+		starts = append(starts, r.URL.Query().Get("start"))
+		switch r.URL.Query().Get("start") {
+		case "0":
+			fmt.Fprint(w, `{"values": [
+				{"slug": "api", "project": {"key": "ACME"}, "links": {"clone": [{"href": "https://git.example.com/scm/acme/api.git", "name": "http"}]}},
+				{"slug": "fork", "project": {"key": "ACME"}, "origin": {"slug": "api"}}
+			], "start": 0, "limit": 2, "isLastPage": false, "nextPageStart": 2}`)
+		case "2":
+			// no nextPageStart, like older servers
+			fmt.Fprint(w, `{"values": [{"slug": "web", "project": {"key": "ACME"}}], "start": 2, "limit": 2, "isLastPage": false}`)
+		case "3":
+			fmt.Fprint(w, `{"values": [{"slug": "db", "project": {"key": "ACME"}}], "start": 3, "limit": 2, "isLastPage": true}`)
+		default:
+			t.Errorf("unexpected start %s", r.URL.Query().Get("start"))
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	opts := options.Options{
+		BitbucketURL:     server.URL + "/bitbucket/",
+		BitbucketProject: "ACME",
+		BitbucketToken:   "token",
+		BitbucketAPIPath: "/proxy/rest/api/1.0",
+		ExcludeForks:     true,
+	}
+	cfg, err := config.NewConfig(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := manager.NewManager(opts, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewBitbucketClient(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos, err := b.listRepos()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, r := range repos {
+		got = append(got, r.Slug)
+	}
+	if want := []string{"api", "web", "db"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got repos %v, wanted %v", got, want)
+	}
+	if want := []string{"0", "2", "3"}; !reflect.DeepEqual(starts, want) {
+		t.Errorf("got pages starting at %v, wanted %v", starts, want)
+	}
+	if got, want := repos[0].cloneURL(), "https://git.example.com/scm/acme/api.git"; got != want {
+		t.Errorf("got clone url %s, wanted %s", got, want)
+	}
+}
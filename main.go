@@ -3,6 +3,7 @@ package main
 import (
 	"io/ioutil"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/zricethezav/gitleaks/v3/audit"
@@ -33,6 +34,13 @@ func main() {
 		log.Error(err)
 		os.Exit(options.ErrorEncountered)
 	}
+	if opts.ListRules {
+		if err := cfg.WriteRules(os.Stdout); err != nil {
+			log.Error(err)
+			os.Exit(options.ErrorEncountered)
+		}
+		os.Exit(options.Success)
+	}
 
 	m, err := manager.NewManager(opts, cfg)
 	if err != nil {
@@ -43,29 +51,70 @@ func main() {
 	err = Run(m)
 	if err != nil {
 		log.Error(err)
-		os.Exit(options.ErrorEncountered)
+		os.Exit(opts.ExitCode(0, err))
 	}
 
-	leaks := m.GetLeaks()
+	leaks := m.LeakCount()
 	metadata := m.GetMetadata()
+	if len(metadata.SkippedCommits) != 0 {
+		log.Warnf("%d commits exceeded the commit timeout and were not fully audited: %s",
+			len(metadata.SkippedCommits), strings.Join(metadata.SkippedCommits, ", "))
+	}
+	if len(metadata.SkippedFiles) != 0 {
+		log.Infof("%d files exceeded the whitelist file size limit and were not audited: %s",
+			len(metadata.SkippedFiles), strings.Join(metadata.SkippedFiles, ", "))
+	}
+	if m.Truncated() {
+		log.Warnf("audit stopped after finding %d leaks, there may be more", m.Opts.MaxLeaks)
+	}
 
-	if len(m.GetLeaks()) != 0 {
-		if m.Opts.CheckUncommitted() {
-			log.Warnf("%d leaks detected in staged changes", len(leaks))
+	// json logs carry the audit's totals as fields so log stores can query them without parsing messages
+	summary := log.NewEntry(log.StandardLogger())
+	if m.Opts.LogFormat == "json" {
+		summary = summary.WithFields(log.Fields{
+			"leaks":     leaks,
+			"commits":   metadata.Commits,
+			"auditTime": time.Duration(metadata.AuditTime).String(),
+			"truncated": m.Truncated(),
+		})
+	}
+
+	if leaks != 0 {
+		if m.Opts.MergeReports != "" {
+			summary.Warnf("%d leaks detected in merged reports", leaks)
+		} else if m.Opts.WorkTree {
+			summary.Warnf("%d leaks detected in work tree", leaks)
+		} else if m.Opts.CheckUncommitted() {
+			summary.Warnf("%d leaks detected in staged changes", leaks)
+		} else if m.Opts.PipeStdin {
+			summary.Warnf("%d leaks detected in diff from stdin", leaks)
+		} else if m.Opts.AuditFilesystem() {
+			summary.Warnf("%d leaks detected in files", leaks)
 		} else {
-			log.Warnf("%d leaks detected. %d commits audited in %s", len(leaks),
+			summary.Warnf("%d leaks detected. %d commits audited in %s", leaks,
 				metadata.Commits, durafmt.Parse(time.Duration(metadata.AuditTime)*time.Nanosecond))
 		}
-		os.Exit(options.LeaksPresent)
 	} else {
-		if m.Opts.CheckUncommitted() {
-			log.Infof("No leaks detected in staged changes")
+		if m.Opts.MergeReports != "" {
+			summary.Infof("No leaks detected in merged reports")
+		} else if m.Opts.WorkTree {
+			summary.Infof("No leaks detected in work tree")
+		} else if m.Opts.CheckUncommitted() {
+			summary.Infof("No leaks detected in staged changes")
+		} else if m.Opts.PipeStdin {
+			summary.Infof("No leaks detected in diff from stdin")
+		} else if m.Opts.AuditFilesystem() {
+			summary.Infof("No leaks detected in files")
 		} else {
-			log.Infof("No leaks detected. %d commits audited in %s",
+			summary.Infof("No leaks detected. %d commits audited in %s",
 				metadata.Commits, durafmt.Parse(time.Duration(metadata.AuditTime)*time.Nanosecond))
 		}
-		os.Exit(options.Success)
 	}
+	failing := m.FailingLeakCount()
+	if leaks != 0 && failing == 0 {
+		log.Infof("no leaks are tagged %s, exiting with 0", m.Opts.FailOnTags)
+	}
+	os.Exit(m.Opts.ExitCode(failing, nil))
 }
 
 // Run begins the program and contains some basic logic on how to continue with the audit. If any external git host
@@ -84,7 +133,9 @@ func Run(m *manager.Manager) error {
 	}
 
 	var err error
-	if m.Opts.Host != "" {
+	if m.Opts.MergeReports != "" {
+		err = m.MergeReports()
+	} else if m.Opts.AuditHost() {
 		err = hosts.Run(m)
 	} else {
 		err = audit.Run(m)
@@ -93,5 +144,12 @@ func Run(m *manager.Manager) error {
 		return err
 	}
 
-	return m.Report()
+	if err := m.Report(); err != nil {
+		return err
+	}
+	if err := m.SaveState(); err != nil {
+		return err
+	}
+	m.Notify()
+	return nil
 }
@@ -0,0 +1,50 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// annotationOutput is where --github-actions prints workflow commands, GitHub Actions reads them from
+// stdout
+var annotationOutput io.Writer = os.Stdout
+
+// annotationData escapes the message of a workflow command, which ends at the end of the line
+var annotationData = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// annotationProperty escapes the value of a workflow command property, which also ends at a comma and
+// can not contain the :: the message starts after
+var annotationProperty = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+// writeAnnotations prints an error workflow command for each leak when --github-actions is set, so
+// leaks are shown as annotations on the lines they were found on in the pull request's diff. Messages
+// include the line the leak was found on with its offender redacted, or masked entirely if the offender
+// is not in it like for entropy leaks, so secrets are never printed to the workflow's log.
+// Leaks found outside of files, like in commit messages, are annotated without a location.
+func writeAnnotations(w io.Writer, leaks []Leak) error {
+	for _, leak := range leaks {
+		redacted := redactLeak(leak, false)
+		properties := []string{"title=" + annotationProperty.Replace(leak.Rule)}
+		if leak.File != "" {
+			properties = append(properties, "file="+annotationProperty.Replace(leak.File))
+			if leak.LineNumber > 0 {
+				properties = append(properties, "line="+strconv.Itoa(leak.LineNumber))
+			}
+		}
+		message := leak.Rule + " detected"
+		if leak.Commit != "" {
+			message += " in commit " + leak.Commit
+		}
+		// leaks of file regexes have no line
+		if redacted.Line != "" && redacted.Line != "N/A" {
+			message += ": " + redacted.Line
+		}
+		if _, err := fmt.Fprintf(w, "::error %s::%s\n", strings.Join(properties, ","), annotationData.Replace(message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
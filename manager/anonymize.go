@@ -0,0 +1,93 @@
+package manager
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// pathTokens maps the tokens that replace repo and file paths when --anonymize-paths is set to the
+// paths they replaced
+type pathTokens struct {
+	mux   sync.Mutex
+	paths map[string]string
+	// key is the random key tokens are keyed with, so tokens can not be recovered by hashing guessed paths
+	key []byte
+}
+
+// newPathTokenKey returns a random key for the tokens of a single report
+func newPathTokenKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// token returns the token that replaces path. Tokens are an HMAC of the path so a path is always
+// replaced by the same token within a report. Empty paths are kept empty.
+func (tokens *pathTokens) token(path string) string {
+	if path == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, tokens.key)
+	mac.Write([]byte(path))
+	token := "path-" + hex.EncodeToString(mac.Sum(nil)[:8])
+	tokens.mux.Lock()
+	defer tokens.mux.Unlock()
+	if tokens.paths == nil {
+		tokens.paths = make(map[string]string)
+	}
+	tokens.paths[token] = path
+	return token
+}
+
+// anonymize returns leaks with their repo and file paths replaced by tokens when --anonymize-paths
+// is set, so reports can be shared without revealing repo names or directory layout. Line numbers,
+// rules, and fingerprints are kept so leaks can still be correlated. The leaks passed in are not
+// changed.
+func (manager *Manager) anonymize(leaks []Leak) []Leak {
+	if !manager.Opts.AnonymizePaths {
+		return leaks
+	}
+	anonymized := make([]Leak, len(leaks))
+	for i, leak := range leaks {
+		anonymized[i] = manager.anonymizeLeak(leak)
+	}
+	return anonymized
+}
+
+// anonymizeLeak replaces the repo and file paths of leak with tokens
+func (manager *Manager) anonymizeLeak(leak Leak) Leak {
+	leak.Repo = manager.pathTokens.token(leak.Repo)
+	leak.File = manager.pathTokens.token(leak.File)
+	leak.OriginalFile = manager.pathTokens.token(leak.OriginalFile)
+	leak.ArchiveEntry = manager.pathTokens.token(leak.ArchiveEntry)
+	if len(leak.Locations) != 0 {
+		locations := make([]Location, len(leak.Locations))
+		for i, l := range leak.Locations {
+			locations[i] = Location{Repo: manager.pathTokens.token(l.Repo), Commit: l.Commit, File: manager.pathTokens.token(l.File)}
+		}
+		leak.Locations = locations
+	}
+	return leak
+}
+
+// pathMappingPath returns the path of the sidecar file --anonymize-paths writes next to report
+func pathMappingPath(report string) string {
+	return report + ".paths.json"
+}
+
+// writePathMapping writes the tokens used by --anonymize-paths and the paths they replaced as a json
+// object next to the report, so the report can be de-anonymized by whoever keeps the sidecar
+func (manager *Manager) writePathMapping() error {
+	manager.pathTokens.mux.Lock()
+	defer manager.pathTokens.mux.Unlock()
+	paths := manager.pathTokens.paths
+	if paths == nil {
+		paths = map[string]string{}
+	}
+	return writeJSONAtomic(pathMappingPath(manager.Opts.Report), paths)
+}
@@ -0,0 +1,140 @@
+package manager
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// acceptAll is the --baseline-accept value that accepts every new leak into the baseline
+const acceptAll = "all"
+
+// baselineKey returns the key used to match a leak against leaks in a baseline.
+// Leaks are considered the same if they share a commit, offender, and file.
+func baselineKey(l Leak) string {
+	return l.Commit + ":" + l.File + ":" + l.Offender
+}
+
+// loadBaseline reads a baseline from a previous json report. A baseline that does not
+// exist yet is treated as empty so that the first --baseline-update can create it.
+func loadBaseline(path string) ([]Leak, error) {
+	baseline := []Leak{}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return baseline, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &baseline); err != nil {
+		return nil, fmt.Errorf("problem loading baseline %s: %v", path, err)
+	}
+	return baseline, nil
+}
+
+// loadBaselineKeys reads a baseline and returns the keys of its leaks
+func loadBaselineKeys(path string) (map[string]bool, error) {
+	baseline, err := loadBaseline(path)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool)
+	for _, l := range baseline {
+		keys[baselineKey(l)] = true
+	}
+	return keys, nil
+}
+
+// inBaseline returns true if leak is in the --baseline. Baselines written by --redact or
+// --redact-partial audits only have redacted offenders so leaks are also matched redacted both ways.
+func (manager *Manager) inBaseline(leak Leak) bool {
+	if len(manager.baseline) == 0 {
+		return false
+	}
+	return manager.baseline[baselineKey(leak)] ||
+		manager.baseline[baselineKey(redactLeak(leak, false))] ||
+		manager.baseline[baselineKey(redactLeak(leak, true))]
+}
+
+// loadAccepted reads a --baseline-accept file. Each line is a "commit:file" pair identifying
+// leaks the user has accepted. Blank lines and lines starting with # are ignored.
+func loadAccepted(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	accepted := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.Contains(line, ":") {
+			return nil, fmt.Errorf("problem loading accepted leaks: %q must be in the form commit:file", line)
+		}
+		accepted[line] = true
+	}
+	return accepted, scanner.Err()
+}
+
+// updateBaseline merges the leaks found during this audit that are not yet in the baseline
+// and that the user has accepted via --baseline-accept into the baseline. Existing baseline
+// entries keep their order and newly accepted leaks are appended in a deterministic order.
+func (manager *Manager) updateBaseline() error {
+	baseline, err := loadBaseline(manager.Opts.Baseline)
+	if err != nil {
+		return err
+	}
+
+	var accepted map[string]bool
+	if manager.Opts.BaselineAccept != acceptAll {
+		accepted, err = loadAccepted(manager.Opts.BaselineAccept)
+		if err != nil {
+			return err
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, l := range baseline {
+		seen[baselineKey(l)] = true
+	}
+
+	var additions []Leak
+	for _, l := range manager.GetLeaks() {
+		if seen[baselineKey(l)] {
+			continue
+		}
+		if accepted != nil && !accepted[l.Commit+":"+l.File] {
+			continue
+		}
+		seen[baselineKey(l)] = true
+		additions = append(additions, l)
+	}
+	sort.Slice(additions, func(i, j int) bool {
+		return baselineKey(additions[i]) < baselineKey(additions[j])
+	})
+
+	if err := writeJSONAtomic(manager.Opts.Baseline, append(baseline, additions...)); err != nil {
+		return err
+	}
+	log.Infof("%d accepted leaks added to baseline %s", len(additions), manager.Opts.Baseline)
+	return nil
+}
+
+// writeJSONAtomic encodes v as indented json and writes it to path with writeFileAtomic
+func writeJSONAtomic(path string, v interface{}) error {
+	return writeFileAtomic(path, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", " ")
+		return encoder.Encode(v)
+	})
+}
@@ -0,0 +1,24 @@
+package manager
+
+import (
+	"strings"
+)
+
+// RecordBranches records the branches each commit in repoName is reachable from when several
+// branches are audited with --branch, by commit hash. Leaks found in the repo are reported with
+// the branches that reach their commit.
+func (manager *Manager) RecordBranches(repoName string, reach map[string][]string) {
+	manager.branchMux.Lock()
+	defer manager.branchMux.Unlock()
+	manager.branches[repoName] = reach
+}
+
+// labelBranches sets the branches of a leak found when several branches are audited
+func (manager *Manager) labelBranches(leak Leak) Leak {
+	manager.branchMux.Lock()
+	defer manager.branchMux.Unlock()
+	if branches := manager.branches[leak.Repo][leak.Commit]; len(branches) != 0 {
+		leak.Branches = strings.Join(branches, ", ")
+	}
+	return leak
+}
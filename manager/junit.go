@@ -0,0 +1,81 @@
+package manager
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// junitTestSuite is a JUnit XML test suite as shown by CI test result panes such as Jenkins' and
+// GitLab's. Only the fields gitleaks populates are defined.
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit encodes leaks as a JUnit XML test suite named gitleaks. Each leak is a failed test case,
+// so the suite's test count is the number of leaks rather than the number of files audited, which
+// gitleaks does not track. A clean audit is written as a single passing test case so CI shows the
+// stage as passed instead of as having no results. Like SARIF reports, offenders are never written.
+func writeJUnit(w io.Writer, leaks []Leak) error {
+	suite := junitTestSuite{Name: "gitleaks"}
+	for _, leak := range leaks {
+		location := junitLocation(leak)
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      location,
+			ClassName: leak.Repo,
+			Failure: &junitFailure{
+				Message: leak.Rule,
+				Type:    leak.Severity,
+				Text:    fmt.Sprintf("%s detected in %s\nfingerprint: %s", leak.Rule, location, leak.Fingerprint),
+			},
+		})
+	}
+	suite.Tests, suite.Failures = len(leaks), len(leaks)
+	if len(leaks) == 0 {
+		suite.Tests = 1
+		suite.TestCases = []junitTestCase{{Name: "no leaks found", ClassName: "gitleaks"}}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", " ")
+	if err := encoder.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitLocation returns where a leak was found, its file and line in a commit or archive entry.
+func junitLocation(leak Leak) string {
+	location := leak.File
+	if leak.ArchiveEntry != "" {
+		location = leak.ArchiveEntry
+	}
+	if leak.LineNumber > 0 {
+		location += ":" + strconv.Itoa(leak.LineNumber)
+	}
+	if leak.Commit != "" {
+		location += " in commit " + leak.Commit
+	}
+	return location
+}
@@ -1,16 +1,27 @@
 package manager
 
 import (
+	"compress/gzip"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/csv"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"text/tabwriter"
+	"text/template"
 	"time"
 
 	"github.com/zricethezav/gitleaks/v3/config"
@@ -18,15 +29,27 @@ import (
 
 	"github.com/hako/durafmt"
 	"github.com/mattn/go-colorable"
+	"github.com/mattn/go-isatty"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/src-d/go-git.v4"
 )
 
 const maxLineLen = 200
 
+// countOutput is where --count-only prints the number of leaks
+var countOutput io.Writer = os.Stderr
+
+// minPartialRedactLen is the shortest offender --redact-partial shows the first and last two
+// characters of. Shorter offenders are fully redacted so most of the secret is still hidden.
+const minPartialRedactLen = 8
+
 // Manager is a struct containing options and configs as well CloneOptions and CloneDir.
 // This struct is passed into each NewRepo so we are not passing around the manager in func params.
 type Manager struct {
+	// leakCount is how many leaks have been kept, streamed or not. It is updated atomically so it can
+	// be read while leaks are received, and is first in the struct so it is 64-bit aligned.
+	leakCount int64
+
 	Opts   options.Options
 	Config config.Config
 
@@ -38,26 +61,157 @@ type Manager struct {
 	leakWG    *sync.WaitGroup
 	leakCache map[string]bool
 
+	// offenderIndex maps a repo's secret to the index of its leak in leaks. It is only
+	// used when --follow-renames is set so that each secret is reported once.
+	offenderIndex map[string]int
+
+	// secretIndex maps a secret to the index of its leak in leaks across all repos. It is only
+	// used when --dedup-secrets is set.
+	secretIndex map[string]int
+
 	stopChan chan os.Signal
 	metadata Metadata
 	metaWG   *sync.WaitGroup
+
+	// runMetadata is the key=value metadata set by --metadata
+	runMetadata map[string]string
+
+	// reportTemplate is the parsed --report-template, nil if one is not set
+	reportTemplate *template.Template
+
+	// pathTokens are the tokens that replaced paths in the report when --anonymize-paths is set
+	pathTokens pathTokens
+
+	// leakLimit is closed once --max-leaks leaks have been collected
+	leakLimit chan struct{}
+
+	// startTime is when the manager was created, the start of the audit
+	startTime time.Time
+
+	// stream is where leaks are written as they are found when --stream is set, nil otherwise
+	stream *leakStream
+
+	// state is the branch tips of each repo audited, loaded from and saved to --state-file
+	state    auditState
+	stateMux sync.Mutex
+
+	// baseline is the keys of the leaks in --baseline, which are known and not reported again
+	baseline map[string]bool
+	// baselined is how many leaks were not reported because they are in the baseline
+	baselined int
+	// failing is how many leaks have a tag in --fail-on-tags
+	failing int
+
+	// renames are the file renames detected in each repo when --follow-renames is set, by repo
+	renames   map[string][]fileRename
+	renameMux sync.Mutex
+
+	// branches are the branches that reach each commit of each repo when several branches are
+	// audited, by repo and commit
+	branches  map[string]map[string][]string
+	branchMux sync.Mutex
+
+	// validations are the statuses of the secrets probed when --validate is set, by rule and secret
+	validations   map[string]*validation
+	validationMux sync.Mutex
 }
 
 // Leak is a struct that contains information about some line of code that contains
-// sensitive information as determined by the rules set in a gitleaks config
+// sensitive information as determined by the rules set in a gitleaks config. Rule is the
+// description of the rule that found the leak.
 type Leak struct {
-	Line       string    `json:"line"`
-	Offender   string    `json:"offender"`
-	Commit     string    `json:"commit"`
-	Repo       string    `json:"repo"`
-	Rule       string    `json:"rule"`
-	Message    string    `json:"commitMessage"`
-	Author     string    `json:"author"`
-	Email      string    `json:"email"`
-	File       string    `json:"file"`
-	Date       time.Time `json:"date"`
-	Tags       string    `json:"tags"`
-	lookupHash string
+	Line     string    `json:"line"`
+	Offender string    `json:"offender"`
+	Commit   string    `json:"commit"`
+	Repo     string    `json:"repo"`
+	Rule     string    `json:"rule"`
+	Message  string    `json:"commitMessage"`
+	Author   string    `json:"author"`
+	Email    string    `json:"email"`
+	File     string    `json:"file"`
+	Date     time.Time `json:"date"`
+	Tags     string    `json:"tags"`
+	Severity string    `json:"severity"`
+
+	// LineNumber is the line of File the leak was found on. Leaks in lines a commit added are
+	// numbered by the commit's version of the file and leaks in lines it removed by the parent's
+	// version, as in a diff. It is 0 if the line is not known, like for file regex leaks.
+	LineNumber int `json:"lineNumber"`
+
+	// Fingerprint is a digest of the leak's commit, file, rule, and line number that identifies the
+	// leak across audits and reports. The offender is left out so the fingerprint is the same whether
+	// or not the leak is redacted. The hash algorithm is set by --fingerprint-hash.
+	Fingerprint string `json:"fingerprint"`
+
+	// Remediation is set from the matched rule and describes how to remediate the leak
+	Remediation string `json:"remediation,omitempty"`
+
+	// Context describes where a secret is used when the offender alone does not, like the
+	// scheme and host of a connection string or the key of a dotenv value
+	Context string `json:"context,omitempty"`
+
+	// ContextBefore and ContextAfter are set when --context is set and are the lines before and after
+	// the leak's line in its file, at most --context of each
+	ContextBefore []string `json:"contextBefore,omitempty"`
+	ContextAfter  []string `json:"contextAfter,omitempty"`
+
+	// Metadata is set from --metadata and stamps each leak with details about the audit run
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Secret is the whole match of a multiline rule, whose offender is only the match's first line,
+	// like a private key's header. It tells apart leaks with the same first line and is scrubbed from
+	// logs, but it is cleared before leaks are kept so it never ends up in a report.
+	Secret string `json:"-"`
+
+	// Locations is set when --dedup-secrets is set and lists everywhere the leak's secret was found
+	Locations []Location `json:"locations,omitempty"`
+
+	// ArchiveEntry is set when --archive-path is set and is the path of the leak's file in the
+	// archive. Nested archives are part of the path. Archive leaks have no commit.
+	ArchiveEntry string `json:"archiveEntry,omitempty"`
+
+	// Encoding is set when the leak's file was not UTF-8 and is the encoding its contents were
+	// transcoded from, like utf-16le. Only files audited from disk or archives are transcoded.
+	Encoding string `json:"encoding,omitempty"`
+
+	// Source is set when the leak was not found in a file. It is message for leaks found in a commit's
+	// message when --scan-messages is set and note for leaks found in git notes when --notes is set.
+	Source string `json:"source,omitempty"`
+
+	// Note is set when --notes is set and the leak was found in a git note. It is the note's ref, like
+	// refs/notes/commits, and Commit is the commit the note annotates.
+	Note string `json:"note,omitempty"`
+
+	// Tag is set when --tags is set and the leak's commit is only reachable from a tag. It is the
+	// first tag, by name, the commit was found from.
+	Tag string `json:"tag,omitempty"`
+
+	// Stash is set when --stash is set and the leak was found in a stash, like stash@{0}. Stashed
+	// changes were never committed to a branch.
+	Stash string `json:"stash,omitempty"`
+
+	// Orphaned is set when --reflog is set and the leak was found in a commit that is only in the
+	// reflog, like a commit amended or rebased away. It is no longer reachable from any ref.
+	Orphaned bool `json:"orphaned,omitempty"`
+
+	// OriginalFile is set when --follow-renames is set and the leak's file was renamed after the leak
+	// was introduced. It is the path the leak was found in and File is the file's latest path.
+	OriginalFile string `json:"originalFile,omitempty"`
+
+	// Validation is set when --validate is set and the leak's rule has a validate probe. It is active,
+	// inactive, or unknown if the probe could not tell.
+	Validation string `json:"validation,omitempty"`
+
+	// Branches is set when several branches are audited with --branch. It is the branches, of those
+	// audited, that the leak's commit is reachable from.
+	Branches string `json:"branches,omitempty"`
+}
+
+// Location identifies where a secret was found
+type Location struct {
+	Repo   string `json:"repo"`
+	Commit string `json:"commit"`
+	File   string `json:"file"`
 }
 
 // AuditTime is a type used to determine total audit time
@@ -89,12 +243,19 @@ type Metadata struct {
 	AuditTime int64
 	patchTime int64
 	cloneTime int64
+
+	// SkippedCommits are the commits not fully audited because they exceeded --commit-timeout
+	SkippedCommits []string
+
+	// SkippedFiles are the files not audited because they exceeded the config's file size limit
+	SkippedFiles []string
 }
 
 func init() {
 	log.SetOutput(os.Stdout)
+	// logs are only colored for people watching them, not when piped into files or log shippers
 	log.SetFormatter(&log.TextFormatter{
-		ForceColors:   true,
+		ForceColors:   isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd()),
 		FullTimestamp: true,
 	})
 	// Fix colors on Windows
@@ -110,6 +271,44 @@ func NewManager(opts options.Options, cfg config.Config) (*Manager, error) {
 	if err != nil {
 		return nil, err
 	}
+	runMetadata, err := opts.ParseMetadata()
+	if err != nil {
+		return nil, err
+	}
+	var reportTemplate *template.Template
+	if opts.ReportTemplate != "" {
+		if reportTemplate, err = loadReportTemplate(opts.ReportTemplate); err != nil {
+			return nil, err
+		}
+	}
+
+	var baseline map[string]bool
+	if opts.Baseline != "" {
+		if baseline, err = loadBaselineKeys(opts.Baseline); err != nil {
+			return nil, err
+		}
+	}
+
+	state := make(auditState)
+	if opts.StateFile != "" {
+		if state, err = loadState(opts.StateFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var pathTokenKey []byte
+	if opts.AnonymizePaths {
+		if pathTokenKey, err = newPathTokenKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	var stream *leakStream
+	if opts.Stream {
+		if stream, err = openStream(opts.Report, opts.GzipReport()); err != nil {
+			return nil, err
+		}
+	}
 
 	m := &Manager{
 		Opts:         opts,
@@ -121,6 +320,20 @@ func NewManager(opts options.Options, cfg config.Config) (*Manager, error) {
 		leakWG:    &sync.WaitGroup{},
 		leakCache: make(map[string]bool),
 		metaWG:    &sync.WaitGroup{},
+
+		offenderIndex:  make(map[string]int),
+		secretIndex:    make(map[string]int),
+		runMetadata:    runMetadata,
+		reportTemplate: reportTemplate,
+		leakLimit:      make(chan struct{}),
+		startTime:      time.Now(),
+		stream:         stream,
+		state:          state,
+		baseline:       baseline,
+		pathTokens:     pathTokens{key: pathTokenKey},
+		renames:        make(map[string][]fileRename),
+		validations:    make(map[string]*validation),
+		branches:       make(map[string]map[string][]string),
 		metadata: Metadata{
 			RegexTime: make(map[string]int64),
 			timings:   make(chan interface{}),
@@ -139,47 +352,346 @@ func NewManager(opts options.Options, cfg config.Config) (*Manager, error) {
 	return m, nil
 }
 
-// GetLeaks returns all available leaks
+// GetLeaks returns a copy of all available leaks. Leaks streamed to the report by --stream are not
+// kept so they are not returned.
 func (manager *Manager) GetLeaks() []Leak {
 	// need to wait for any straggling leaks
 	manager.leakWG.Wait()
-	return manager.leaks
+	manager.followRenames()
+	if manager.leaks == nil {
+		return nil
+	}
+	return append([]Leak{}, manager.leaks...)
+}
+
+// LeakCount returns how many leaks were found, including leaks streamed to the report by --stream
+func (manager *Manager) LeakCount() int {
+	manager.leakWG.Wait()
+	return manager.GetLeakCount()
+}
+
+// FailingLeakCount returns how many leaks fail the audit, those of rules with a tag in --fail-on-tags,
+// or all leaks if it is not set. It decides the exit code so CI can gate on some rules while every
+// leak is still reported.
+func (manager *Manager) FailingLeakCount() int {
+	manager.leakWG.Wait()
+	if manager.Opts.FailOnTags == "" {
+		return manager.GetLeakCount()
+	}
+	return manager.failing
+}
+
+// failsAudit returns true if leak has one of the tags in --fail-on-tags. Tags are matched
+// case-insensitively.
+func (manager *Manager) failsAudit(leak Leak) bool {
+	if manager.Opts.FailOnTags == "" {
+		return false
+	}
+	for _, tag := range strings.Split(leak.Tags, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		for _, failOn := range strings.Split(manager.Opts.FailOnTags, ",") {
+			if tag == strings.ToLower(strings.TrimSpace(failOn)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetLeakCount returns how many leaks have been kept so far, including leaks streamed to the report
+// by --stream. Unlike LeakCount it does not wait for leaks that were sent but not yet received, and
+// unlike GetLeaks it does not copy the leaks, so it is cheap to call while an audit runs.
+func (manager *Manager) GetLeakCount() int {
+	return int(atomic.LoadInt64(&manager.leakCount))
 }
 
 // SendLeaks accepts a leak and is used by the audit pkg. This is the public function
 // that allows other packages to send leaks to the manager.
 func (manager *Manager) SendLeaks(l Leak) {
+	// offenders are scrubbed from logs even if the leak is not reported
+	if offenderIsSecret(l) {
+		logScrubber.add(l.Offender)
+	}
+	for _, line := range strings.Split(l.Secret, "\n") {
+		logScrubber.add(strings.TrimSpace(line))
+	}
 	if len(l.Line) > maxLineLen {
 		l.Line = l.Line[0:maxLineLen-1] + "..."
 	}
 	if len(l.Offender) > maxLineLen {
 		l.Offender = l.Offender[0:maxLineLen-1] + "..."
 	}
-	h := sha1.New()
-	h.Write([]byte(l.Commit + l.Offender + l.File))
-	l.lookupHash = hex.EncodeToString(h.Sum(nil))
+	if len(manager.runMetadata) != 0 {
+		l.Metadata = manager.runMetadata
+	}
+	if l.Severity == "" {
+		l.Severity = config.DefaultSeverity
+	}
+	if manager.Opts.MinSeverity != "" &&
+		config.SeverityRank(l.Severity) < config.SeverityRank(manager.Opts.MinSeverity) {
+		return
+	}
+	if l.ArchiveEntry != "" || manager.Opts.AuditFilesystem() || manager.Opts.WorkTree {
+		// archive entries and files on disk are located by their path, not a commit
+		l.Commit = ""
+	}
+	if manager.Opts.DedupSecrets {
+		l.Locations = []Location{leakLocation(l)}
+	}
+	// leaks resumed from an earlier report already have a fingerprint, computed before they were redacted
+	if l.Fingerprint == "" {
+		l.Fingerprint = manager.fingerprint(l)
+	}
 	manager.leakWG.Add(1)
 	manager.leakChan <- l
 }
 
+// fingerprint hashes a leak's commit, file, rule, and line number with the algorithm set by
+// --fingerprint-hash. Leaks found in archives are hashed with their archive entry in place of a commit.
+// The file is the path the leak was found in, not the latest path of a renamed file, so fingerprints
+// are the same whether or not --follow-renames is set. Fields are separated by NUL bytes and paths use
+// forward slashes so fingerprints are the same across runs and platforms.
+func (manager *Manager) fingerprint(l Leak) string {
+	var h hash.Hash
+	if manager.Opts.FingerprintHash == "sha1" {
+		h = sha1.New()
+	} else {
+		h = sha256.New()
+	}
+	commit := l.Commit
+	if l.ArchiveEntry != "" {
+		commit = l.ArchiveEntry
+	}
+	file := l.File
+	if l.OriginalFile != "" {
+		file = l.OriginalFile
+	}
+	h.Write([]byte(strings.Join([]string{commit, filepath.ToSlash(file), l.Rule, strconv.Itoa(l.LineNumber)}, "\x00")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// alreadySeen returns true if a leak of the same offender in the same commit and file has been
+// received, like when a commit is reached by more than one walk
 func (manager *Manager) alreadySeen(leak Leak) bool {
-	if _, ok := manager.leakCache[leak.lookupHash]; ok {
+	key := leak.Commit + ":" + leak.ArchiveEntry + ":" + leak.File + ":" + offenderValue(leak)
+	if manager.leakCache[key] {
 		return true
 	}
-	manager.leakCache[leak.lookupHash] = true
+	manager.leakCache[key] = true
+	return false
+}
+
+// alreadyFound is used when --dedup-secrets is set to collapse every leak of the same secret, across
+// all audited repos, into a single leak listing each location of the secret. Repos may be audited
+// concurrently so leaks arrive in any order. To keep reports deterministic the oldest leak is kept,
+// with ties broken by location, and locations are kept sorted. The index is only accessed by
+// receiveLeaks, which handles one leak at a time, so it needs no locking of its own.
+func (manager *Manager) alreadyFound(leak Leak) bool {
+	if !manager.Opts.DedupSecrets {
+		return false
+	}
+	i, ok := manager.secretIndex[secretKey(leak)]
+	if !ok {
+		// the leak is indexed by receiveLeaks once it has been kept
+		return false
+	}
+	existing := manager.leaks[i]
+	locations := existing.Locations
+	for _, loc := range leak.Locations {
+		if !containsLocation(locations, loc) {
+			locations = append(locations, loc)
+		}
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		return locationLess(locations[i], locations[j])
+	})
+	if leak.Date.Before(existing.Date) ||
+		(leak.Date.Equal(existing.Date) && locationLess(leakLocation(leak), leakLocation(existing))) {
+		existing = manager.redact(leak)
+	}
+	existing.Locations = locations
+	manager.leaks[i] = existing
+	return true
+}
+
+// secretKey identifies a leak's secret across repos for --dedup-secrets
+func secretKey(leak Leak) string {
+	return leak.Rule + ":" + offenderValue(leak)
+}
+
+// leakLocation returns the location a leak was found at
+func leakLocation(leak Leak) Location {
+	if leak.ArchiveEntry != "" {
+		return Location{Repo: leak.Repo, File: leak.ArchiveEntry}
+	}
+	return Location{Repo: leak.Repo, Commit: leak.Commit, File: leak.File}
+}
+
+func containsLocation(locations []Location, loc Location) bool {
+	for _, l := range locations {
+		if l == loc {
+			return true
+		}
+	}
 	return false
 }
 
+// locationLess orders locations by repo, then commit, then file
+func locationLess(a, b Location) bool {
+	if a.Repo != b.Repo {
+		return a.Repo < b.Repo
+	}
+	if a.Commit != b.Commit {
+		return a.Commit < b.Commit
+	}
+	return a.File < b.File
+}
+
+// alreadyIntroduced is used when --follow-renames is set to report each secret in a repo once,
+// attributed to the commit that introduced it. Unless --traversal-order=topo is set, commits are
+// not guaranteed to be audited parents first so if a leak for the same secret has already been
+// received, the older of the two leaks is kept.
+func (manager *Manager) alreadyIntroduced(leak Leak) bool {
+	if !manager.Opts.FollowRenames {
+		return false
+	}
+	key := leak.Repo + ":" + leak.Rule + ":" + offenderValue(leak)
+	i, ok := manager.offenderIndex[key]
+	if !ok {
+		manager.offenderIndex[key] = len(manager.leaks)
+		return false
+	}
+	if manager.Opts.TraversalOrder == "topo" {
+		// parents are walked before their children so the first leak seen for a secret is
+		// from the commit that introduced it, even if commit dates are skewed
+		return true
+	}
+	existing := manager.leaks[i]
+	if leak.Date.Before(existing.Date) ||
+		(leak.Date.Equal(existing.Date) && leak.Commit < existing.Commit) {
+		manager.leaks[i] = manager.redact(leak)
+	}
+	return true
+}
+
+// offenderValue returns the value that identifies the secret a leak was reported for. Leaks where
+// the offender is not on the leaked line, like entropy or file name leaks, are identified by both.
+// Leaks of multiline rules are identified by their whole match.
+func offenderValue(leak Leak) string {
+	if leak.Secret != "" {
+		return leak.Secret
+	}
+	if strings.Contains(leak.Line, leak.Offender) {
+		return leak.Offender
+	}
+	return leak.Offender + leak.Line
+}
+
+// redact masks a leak's offender, and the offender wherever it appears in the leak's line, when
+// --redact or --redact-partial is set. Leaks are redacted by the manager as they are kept rather than
+// when they are found so every report format, log, and baseline only sees redacted leaks, while leaks
+// can still be told apart by their offenders for deduplication. The whole match of a multiline rule is
+// cleared whether or not leaks are redacted.
+func (manager *Manager) redact(leak Leak) Leak {
+	leak.Secret = ""
+	if !manager.Opts.Redact && !manager.Opts.RedactPartial {
+		return leak
+	}
+	return redactLeak(leak, manager.Opts.RedactPartial)
+}
+
+// redactLeak masks leak's offender in the offender and line. If partial is set offenders keep their
+// first and last two characters. Leaks whose offender is not in their line, like entropy leaks whose
+// offender is the entropy range tripped, have their whole line and context masked since the secret
+// could be anywhere in them.
+func redactLeak(leak Leak, partial bool) Leak {
+	masked := "REDACTED"
+	if partial && len(leak.Offender) >= minPartialRedactLen {
+		masked = leak.Offender[:2] + strings.Repeat("*", len(leak.Offender)-4) + leak.Offender[len(leak.Offender)-2:]
+	}
+	switch {
+	case leak.Offender != "" && strings.Contains(leak.Line, leak.Offender):
+		leak.Line = strings.ReplaceAll(leak.Line, leak.Offender, masked)
+		leak.ContextBefore = redactLines(leak.ContextBefore, leak.Offender, masked)
+		leak.ContextAfter = redactLines(leak.ContextAfter, leak.Offender, masked)
+	// leaks of file regexes have no line
+	case leak.Line != "" && leak.Line != "N/A":
+		leak.Line = "REDACTED"
+		leak.ContextBefore = maskLines(leak.ContextBefore)
+		leak.ContextAfter = maskLines(leak.ContextAfter)
+	}
+	leak.Offender = masked
+	return leak
+}
+
+// redactLines returns a copy of lines with offender replaced by masked. Lines are copied so the
+// unredacted leak is left as it was.
+func redactLines(lines []string, offender, masked string) []string {
+	if lines == nil {
+		return nil
+	}
+	redacted := make([]string, len(lines))
+	for i, line := range lines {
+		redacted[i] = strings.ReplaceAll(line, offender, masked)
+	}
+	return redacted
+}
+
+// maskLines returns a copy of lines with every line replaced by REDACTED
+func maskLines(lines []string) []string {
+	if lines == nil {
+		return nil
+	}
+	masked := make([]string, len(lines))
+	for i := range lines {
+		masked[i] = "REDACTED"
+	}
+	return masked
+}
+
 // receiveLeaks listens to leakChan for incoming leaks. If any are received, they are appended to the
 // manager's leaks for future reporting. If the -v/--verbose option is set the leaks will marshaled into
 // json and printed out.
 func (manager *Manager) receiveLeaks() {
 	for leak := range manager.leakChan {
-		if manager.alreadySeen(leak) {
+		if manager.Truncated() {
+			// leaks sent by workers that have not yet seen the limit are dropped
+			manager.leakWG.Done()
+			continue
+		}
+		if manager.inBaseline(leak) {
+			manager.baselined++
+			manager.leakWG.Done()
+			continue
+		}
+		// alreadyFound is checked first since leaks in different repos can share a commit when
+		// repos share history, and each of their locations should still be recorded
+		if manager.alreadyFound(leak) || manager.alreadySeen(leak) || manager.alreadyIntroduced(leak) {
 			manager.leakWG.Done()
 			continue
 		}
-		manager.leaks = append(manager.leaks, leak)
+		if manager.Opts.DedupSecrets {
+			manager.secretIndex[secretKey(leak)] = len(manager.leaks)
+		}
+		leak = manager.labelBranches(leak)
+		leak = manager.redact(leak)
+		if manager.stream != nil && manager.Opts.AnonymizePaths {
+			manager.stream.write(manager.anonymizeLeak(leak))
+		} else if manager.stream != nil {
+			manager.stream.write(leak)
+		} else {
+			manager.leaks = append(manager.leaks, leak)
+		}
+		kept := atomic.AddInt64(&manager.leakCount, 1)
+		if manager.failsAudit(leak) {
+			manager.failing++
+		}
+		if manager.Opts.MaxLeaks != 0 && kept == int64(manager.Opts.MaxLeaks) {
+			close(manager.leakLimit)
+		}
 		if manager.Opts.Verbose {
 			var b []byte
 			if manager.Opts.PrettyPrint {
@@ -193,6 +705,23 @@ func (manager *Manager) receiveLeaks() {
 	}
 }
 
+// LeakLimit returns a channel that is closed once --max-leaks leaks have been collected. Audits
+// stop when it is closed.
+func (manager *Manager) LeakLimit() <-chan struct{} {
+	return manager.leakLimit
+}
+
+// Truncated returns true if --max-leaks was reached, in which case the leaks collected are not all of
+// the leaks in the audited repos.
+func (manager *Manager) Truncated() bool {
+	select {
+	case <-manager.leakLimit:
+		return true
+	default:
+		return false
+	}
+}
+
 // GetMetadata returns the metadata. TODO this may not need to be private
 func (manager *Manager) GetMetadata() Metadata {
 	manager.metaWG.Wait()
@@ -225,6 +754,31 @@ func (manager *Manager) IncrementCommits(i int) {
 	manager.metadata.mux.Unlock()
 }
 
+// SkipCommit records that commit was not fully audited because it exceeded --commit-timeout
+func (manager *Manager) SkipCommit(commit string) {
+	manager.metadata.mux.Lock()
+	defer manager.metadata.mux.Unlock()
+	for _, skipped := range manager.metadata.SkippedCommits {
+		if skipped == commit {
+			return
+		}
+	}
+	log.Warnf("commit %s exceeded the commit timeout, skipping", commit)
+	manager.metadata.SkippedCommits = append(manager.metadata.SkippedCommits, commit)
+}
+
+// SkipFile records that file was not audited because it exceeded the config's file size limit
+func (manager *Manager) SkipFile(file string) {
+	manager.metadata.mux.Lock()
+	defer manager.metadata.mux.Unlock()
+	for _, skipped := range manager.metadata.SkippedFiles {
+		if skipped == file {
+			return
+		}
+	}
+	manager.metadata.SkippedFiles = append(manager.metadata.SkippedFiles, file)
+}
+
 // RecordTime accepts an interface and sends it to the manager's time channel
 func (manager *Manager) RecordTime(t interface{}) {
 	manager.metaWG.Add(1)
@@ -240,6 +794,7 @@ func (manager *Manager) DebugOutput() {
 	fmt.Println("totalPatchTime: ", durafmt.Parse(time.Duration(manager.metadata.patchTime)*time.Nanosecond))
 	fmt.Println("totalCloneTime: ", durafmt.Parse(time.Duration(manager.metadata.cloneTime)*time.Nanosecond))
 	fmt.Println("totalCommits: ", manager.metadata.Commits)
+	fmt.Println("skippedCommits: ", len(manager.metadata.SkippedCommits))
 
 	const padding = 6
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, padding, '.', 0)
@@ -263,41 +818,301 @@ func (manager *Manager) Report() error {
 		manager.DebugOutput()
 	}
 
+	manager.leakWG.Wait()
+	if manager.baselined != 0 {
+		log.Infof("%d leaks found in baseline %s were not reported", manager.baselined, manager.Opts.Baseline)
+	}
+
+	if manager.Opts.GithubActions {
+		if err := writeAnnotations(annotationOutput, manager.GetLeaks()); err != nil {
+			return err
+		}
+	}
+
+	if manager.stream != nil {
+		// leaks were written to the report as they were found
+		manager.leakWG.Wait()
+		if err := manager.stream.close(); err != nil {
+			return err
+		}
+		log.Infof("%d leaks streamed to %s", manager.stream.written(), manager.Opts.Report)
+		if manager.Opts.AnonymizePaths {
+			return manager.writePathMapping()
+		}
+		return nil
+	}
+
+	if manager.Opts.CountOnly {
+		// leaks are only counted so no report is written, redacted or not
+		fmt.Fprintln(countOutput, manager.LeakCount())
+		return nil
+	}
+
 	if manager.Opts.Report != "" {
-		if len(manager.GetLeaks()) == 0 {
+		// junit and per severity reports are written for clean audits too so CI shows the audit passed
+		if len(manager.GetLeaks()) == 0 && manager.Opts.ReportFormat != "junit" && !manager.Opts.SplitBySeverity {
 			log.Infof("no leaks found, skipping writing report")
 			return nil
 		}
-		file, err := os.Create(manager.Opts.Report)
-		if err != nil {
-			return err
+		if manager.Opts.DedupSecrets || manager.Opts.OwnerThreads > 1 {
+			// leaks from concurrently audited repos arrive in any order
+			manager.sortLeaks()
 		}
-
-		if manager.Opts.ReportFormat == "json" {
-			encoder := json.NewEncoder(file)
-			encoder.SetIndent("", " ")
-			err = encoder.Encode(manager.leaks)
-			if err != nil {
+		if manager.Opts.SplitBySeverity {
+			if err := manager.writeSeverityReports(); err != nil {
 				return err
 			}
 		} else {
-			w := csv.NewWriter(file)
-			_ = w.Write([]string{"repo", "line", "commit", "offender", "rule", "tags", "commitMsg", "author", "email", "file", "date"})
-			for _, leak := range manager.GetLeaks() {
-				w.Write([]string{leak.Repo, leak.Line, leak.Commit, leak.Offender, leak.Rule, leak.Tags, leak.Message, leak.Author, leak.Email, leak.File, leak.Date.Format(time.RFC3339)})
+			if err := manager.writeReport(manager.Opts.Report, manager.leaks); err != nil {
+				return err
 			}
-			w.Flush()
+			log.Infof("report written to %s", manager.Opts.Report)
+		}
+		if manager.Opts.AnonymizePaths {
+			if err := manager.writePathMapping(); err != nil {
+				return err
+			}
+			log.Infof("anonymized paths written to %s", pathMappingPath(manager.Opts.Report))
+		}
+	}
+
+	if manager.Opts.BaselineUpdate {
+		return manager.updateBaseline()
+	}
+	return nil
+}
+
+// Close stops the manager from receiving leaks, metadata, and interrupts. It is used in place of Report
+// when leaks are read with GetLeaks rather than reported, so the manager's goroutines and its interrupt
+// handler do not outlive it.
+func (manager *Manager) Close() {
+	manager.GetLeaks()
+	signal.Stop(manager.stopChan)
+	close(manager.stopChan)
+	close(manager.leakChan)
+	manager.metaWG.Wait()
+	close(manager.metadata.timings)
+	if manager.stream != nil {
+		if err := manager.stream.close(); err != nil {
+			log.Errorf("could not write streamed leaks to %s: %v", manager.Opts.Report, err)
+		}
+	}
+}
+
+// reportEnvelope is the json report written when --report-envelope is set or --max-leaks stopped the
+// audit early. Other json reports are a bare list of leaks so existing consumers keep working.
+type reportEnvelope struct {
+	Scan      *scanSummary `json:"scan,omitempty"`
+	Leaks     []Leak       `json:"leaks"`
+	Truncated bool         `json:"truncated,omitempty"`
+}
+
+// scanSummary records where the leaks of a --report-envelope report came from
+type scanSummary struct {
+	Repo           string    `json:"repo"`
+	StartTime      time.Time `json:"startTime"`
+	EndTime        time.Time `json:"endTime"`
+	CommitsScanned int       `json:"commitsScanned"`
+	LeaksFound     int       `json:"leaksFound"`
+}
+
+// reportEnvelope wraps leaks with the audit's summary if --report-envelope is set and whether the
+// audit was stopped by --max-leaks
+func (manager *Manager) reportEnvelope(leaks []Leak) reportEnvelope {
+	envelope := reportEnvelope{Leaks: leaks, Truncated: manager.Truncated()}
+	if manager.Opts.ReportEnvelope {
+		envelope.Scan = &scanSummary{
+			Repo:           manager.Opts.Target(),
+			StartTime:      manager.startTime,
+			EndTime:        time.Now(),
+			CommitsScanned: manager.GetMetadata().Commits,
+			LeaksFound:     len(leaks),
+		}
+	}
+	return envelope
+}
+
+// writeReport writes leaks to path in the format set by --report-format, compressed with gzip if
+// --gzip is set or path ends in .gz. Paths are anonymized if --anonymize-paths is set.
+func (manager *Manager) writeReport(path string, leaks []Leak) error {
+	leaks = manager.anonymize(leaks)
+	return writeFileAtomic(path, func(file io.Writer) error {
+		if !manager.Opts.GzipReport() {
+			return manager.encodeReport(file, leaks)
+		}
+		gz := gzip.NewWriter(file)
+		if err := manager.encodeReport(gz, leaks); err != nil {
+			gz.Close()
+			return err
+		}
+		return gz.Close()
+	})
+}
+
+// encodeReport encodes leaks to file in the format set by --report-format
+func (manager *Manager) encodeReport(file io.Writer, leaks []Leak) error {
+	if manager.reportTemplate != nil {
+		return manager.writeTemplate(file, leaks)
+	}
+	switch manager.Opts.ReportFormat {
+	case "json":
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", " ")
+		if manager.Opts.ReportEnvelope || manager.Truncated() {
+			return encoder.Encode(manager.reportEnvelope(leaks))
+		}
+		return encoder.Encode(leaks)
+	case "sarif":
+		return writeSARIF(file, leaks)
+	case "junit":
+		return writeJUnit(file, leaks)
+	case "sonar":
+		return writeSonar(file, leaks)
+	}
+	w := csv.NewWriter(file)
+	_ = w.Write(csvHeader)
+	for _, leak := range leaks {
+		w.Write(csvRow(leak))
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// csvHeader is the header row of csv reports. The leading columns identify a leak and their order is
+// stable so reports can be loaded into spreadsheets, newer columns are added at the end.
+var csvHeader = []string{"repo", "commit", "offender", "rule", "file", "date", "author", "line", "tags", "commitMsg", "email", "metadata", "remediation", "fingerprint", "context", "severity", "locations", "archiveEntry", "lineNumber", "tag", "contextBefore", "contextAfter", "stash", "originalFile", "validation", "branches", "orphaned"}
+
+// csvRow returns the csv report row of leak in the order of csvHeader. Dates are ISO-8601 and
+// fields a leak does not have, like the date of an archive leak or an unknown line number, are blank.
+func csvRow(leak Leak) []string {
+	var date, lineNumber string
+	if !leak.Date.IsZero() && leak.ArchiveEntry == "" {
+		date = leak.Date.Format(time.RFC3339)
+	}
+	if leak.LineNumber > 0 {
+		lineNumber = strconv.Itoa(leak.LineNumber)
+	}
+	return []string{leak.Repo, leak.Commit, leak.Offender, leak.Rule, leak.File, date, leak.Author, leak.Line, leak.Tags, leak.Message, leak.Email, formatMetadata(leak.Metadata), leak.Remediation, leak.Fingerprint, leak.Context, leak.Severity, formatLocations(leak.Locations), leak.ArchiveEntry, lineNumber, leak.Tag, strings.Join(leak.ContextBefore, "\n"), strings.Join(leak.ContextAfter, "\n"), leak.Stash, leak.OriginalFile, leak.Validation, leak.Branches, strconv.FormatBool(leak.Orphaned)}
+}
+
+// writeSeverityReports is used when --split-by-severity is set to write a report for each severity
+// level, like report.critical.json. Severity levels without leaks get an empty report unless
+// --skip-empty-severities is set.
+func (manager *Manager) writeSeverityReports() error {
+	bySeverity := make(map[string][]Leak)
+	for _, leak := range manager.leaks {
+		bySeverity[leak.Severity] = append(bySeverity[leak.Severity], leak)
+	}
+	severities := append([]string{}, config.Severities...)
+	for severity := range bySeverity {
+		if !contains(severities, severity) {
+			severities = append(severities, severity)
 		}
-		_ = file.Close()
+	}
 
-		log.Infof("report written to %s", manager.Opts.Report)
+	for _, severity := range severities {
+		leaks := bySeverity[severity]
+		if len(leaks) == 0 {
+			if manager.Opts.SkipEmptySeverities {
+				continue
+			}
+			leaks = []Leak{}
+		}
+		path := severityReportPath(manager.Opts.Report, severity)
+		if err := manager.writeReport(path, leaks); err != nil {
+			return err
+		}
+		log.Infof("%d %s leaks written to %s", len(leaks), severity, path)
 	}
 	return nil
 }
 
+// severityReportPath inserts severity before the extension of a report path, so report.json
+// becomes report.critical.json and report.json.gz becomes report.critical.json.gz
+func severityReportPath(report, severity string) string {
+	gz := ""
+	if strings.HasSuffix(report, ".gz") {
+		report, gz = strings.TrimSuffix(report, ".gz"), ".gz"
+	}
+	ext := filepath.Ext(report)
+	return strings.TrimSuffix(report, ext) + "." + severity + ext + gz
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFileAtomic calls write with a temporary file next to path and then renames the file over
+// path so readers never observe a partially written file, even if gitleaks is interrupted.
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	// temp files are created with 0600, keep the permissions os.Create would have used
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// sortLeaks sorts leaks by repo, commit, file, and then offender
+func (manager *Manager) sortLeaks() {
+	sort.Slice(manager.leaks, func(i, j int) bool {
+		a, b := manager.leaks[i], manager.leaks[j]
+		if a.Repo != b.Repo || a.Commit != b.Commit || a.File != b.File {
+			return locationLess(leakLocation(a), leakLocation(b))
+		}
+		return a.Offender < b.Offender
+	})
+}
+
+// formatLocations formats locations as repo:commit:file separated by semicolons
+func formatLocations(locations []Location) string {
+	var formatted []string
+	for _, l := range locations {
+		formatted = append(formatted, l.Repo+":"+l.Commit+":"+l.File)
+	}
+	return strings.Join(formatted, ";")
+}
+
+// formatMetadata formats metadata as key=value pairs separated by semicolons, sorted by key
+func formatMetadata(metadata map[string]string) string {
+	var pairs []string
+	for k, v := range metadata {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ";")
+}
+
 func (manager *Manager) receiveInterrupt() {
-	<-manager.stopChan
-	if manager.Opts.Report != "" {
+	if _, ok := <-manager.stopChan; !ok {
+		// the manager was closed
+		return
+	}
+	if manager.Opts.Report != "" || manager.Opts.CountOnly {
 		err := manager.Report()
 		if err != nil {
 			log.Error(err)
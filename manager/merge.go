@@ -0,0 +1,84 @@
+package manager
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MergeReports is used when --merge-reports is set to combine the json reports of audits run
+// separately, like shards of a repo audited in parallel, into the manager's leaks so they are
+// written to --report in any report format. Leaks are identified by their fingerprint so a leak found
+// by several audits is kept once, whether or not the reports were redacted. Leaks keep the order of
+// the reports they were read from.
+func (manager *Manager) MergeReports() error {
+	seen := make(map[string]bool)
+	reports := manager.Opts.MergeReportPaths()
+	merged, duplicates := 0, 0
+	for _, report := range reports {
+		leaks, err := loadReport(report)
+		if err != nil {
+			return err
+		}
+		for _, leak := range leaks {
+			if leak.Fingerprint == "" {
+				leak.Fingerprint = manager.fingerprint(leak)
+			}
+			if seen[leak.Fingerprint] {
+				duplicates++
+				continue
+			}
+			seen[leak.Fingerprint] = true
+			if manager.inBaseline(leak) {
+				manager.baselined++
+				continue
+			}
+			manager.leaks = append(manager.leaks, manager.redact(leak))
+			atomic.AddInt64(&manager.leakCount, 1)
+			merged++
+		}
+	}
+	log.Infof("%d leaks merged from %d reports, %d duplicate leaks were dropped", merged, len(reports), duplicates)
+	return nil
+}
+
+// gzipMagic are the first bytes of a gzip file
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// loadReport reads the leaks of a json report, either a list of leaks or a --report-envelope report.
+// Reports compressed with --gzip are decompressed.
+func loadReport(path string) ([]Leak, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(b, gzipMagic) {
+		gz, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("problem loading report %s: %v", path, err)
+		}
+		if b, err = ioutil.ReadAll(gz); err != nil {
+			return nil, fmt.Errorf("problem loading report %s: %v", path, err)
+		}
+	}
+	var leaks []Leak
+	if strings.HasPrefix(strings.TrimSpace(string(b)), "{") {
+		var envelope reportEnvelope
+		if err := json.Unmarshal(b, &envelope); err != nil {
+			return nil, fmt.Errorf("problem loading report %s: %v", path, err)
+		}
+		if envelope.Truncated {
+			log.Warnf("report %s was truncated by --max-leaks, there may be more leaks than were merged", path)
+		}
+		leaks = envelope.Leaks
+	} else if err := json.Unmarshal(b, &leaks); err != nil {
+		return nil, fmt.Errorf("problem loading report %s: %v", path, err)
+	}
+	return leaks, nil
+}
@@ -0,0 +1,67 @@
+package manager
+
+import (
+	"time"
+)
+
+// fileRename is a file renamed by a commit authored at date
+type fileRename struct {
+	from, to string
+	date     time.Time
+}
+
+// RecordRename records that a commit authored at date in repoName renamed the file from to to. Leaks
+// found in from before the rename are reported with the file's latest path when --follow-renames is set.
+func (manager *Manager) RecordRename(repoName, from, to string, date time.Time) {
+	manager.renameMux.Lock()
+	defer manager.renameMux.Unlock()
+	manager.renames[repoName] = append(manager.renames[repoName], fileRename{from: from, to: to, date: date})
+}
+
+// followRenames sets the file of each leak whose file was renamed after the leak was introduced to the
+// file's latest path, keeping the path it was found in as its original file. Renames are followed by
+// path and date so a file later added back at a renamed file's old path is not mistaken for it. Leaks
+// already followed are followed again from their original file so renames recorded since are included.
+func (manager *Manager) followRenames() {
+	manager.renameMux.Lock()
+	defer manager.renameMux.Unlock()
+	if len(manager.renames) == 0 {
+		return
+	}
+	for i, leak := range manager.leaks {
+		renames := manager.renames[leak.Repo]
+		if len(renames) == 0 {
+			continue
+		}
+		original := leak.File
+		if leak.OriginalFile != "" {
+			original = leak.OriginalFile
+		}
+		if latest := latestPath(renames, original, leak.Date); latest != original {
+			manager.leaks[i].File = latest
+			manager.leaks[i].OriginalFile = original
+		}
+	}
+}
+
+// latestPath follows the renames of file made at or after since and returns the file's latest path
+func latestPath(renames []fileRename, file string, since time.Time) string {
+	// each rename is followed at most once so a file renamed back and forth can not loop
+	followed := make(map[int]bool)
+	for {
+		next := -1
+		for i, r := range renames {
+			if followed[i] || r.from != file || r.date.Before(since) {
+				continue
+			}
+			if next == -1 || r.date.Before(renames[next].date) {
+				next = i
+			}
+		}
+		if next == -1 {
+			return file
+		}
+		followed[next] = true
+		file, since = renames[next].to, renames[next].date
+	}
+}
@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resumeManifest is the name of the file in the resume directory listing repos whose audit
+// has completed, one per line.
+const resumeManifest = "manifest"
+
+// resumeDir returns the directory used by --resume to track the progress of an --owner-path
+// audit. It holds the manifest of completed repos and a json report for each of them.
+func (manager *Manager) resumeDir() string {
+	return manager.Opts.Report + ".resume"
+}
+
+// CompletedRepos returns the repos recorded in the resume manifest by a previous --resume run.
+// A manifest that does not exist yet means no repos have been completed. Only lines terminated
+// by a newline are trusted since the final line may have been cut short by an interruption.
+func (manager *Manager) CompletedRepos() (map[string]bool, error) {
+	completed := make(map[string]bool)
+	b, err := ioutil.ReadFile(filepath.Join(manager.resumeDir(), resumeManifest))
+	if os.IsNotExist(err) {
+		return completed, nil
+	} else if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(b), "\n")
+	for _, line := range lines[:len(lines)-1] {
+		if line != "" {
+			completed[line] = true
+		}
+	}
+	return completed, nil
+}
+
+// ResumeRepo loads the report of a repo completed by a previous --resume run and sends its
+// leaks to the manager so they are included in this run's report without auditing the repo again.
+func (manager *Manager) ResumeRepo(repoName string) error {
+	b, err := ioutil.ReadFile(manager.repoReportPath(repoName))
+	if err != nil {
+		return err
+	}
+	var leaks []Leak
+	if err := json.Unmarshal(b, &leaks); err != nil {
+		return fmt.Errorf("problem loading resumed report for %s: %v", repoName, err)
+	}
+	for _, l := range leaks {
+		manager.SendLeaks(l)
+	}
+	return nil
+}
+
+// CompleteRepo records that repoName has been fully audited. The repo's leaks are written to
+// their own report first so that a repo listed in the manifest always has a complete report.
+func (manager *Manager) CompleteRepo(repoName string) error {
+	if err := os.MkdirAll(manager.resumeDir(), 0755); err != nil {
+		return err
+	}
+
+	leaks := []Leak{}
+	for _, l := range manager.GetLeaks() {
+		if l.Repo == repoName {
+			leaks = append(leaks, l)
+		}
+	}
+	if err := writeJSONAtomic(manager.repoReportPath(repoName), leaks); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(manager.resumeDir(), resumeManifest),
+		os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(repoName + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// repoReportPath returns the path of the report --resume keeps for a completed repo
+func (manager *Manager) repoReportPath(repoName string) string {
+	return filepath.Join(manager.resumeDir(), repoName+".json")
+}
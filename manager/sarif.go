@@ -0,0 +1,149 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/zricethezav/gitleaks/v3/version"
+)
+
+const (
+	sarifSchema  = "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// sarifReport is a SARIF 2.1.0 log as consumed by code scanning tools such as GitHub's.
+// Only the fields gitleaks populates are defined.
+type sarifReport struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	Name             string        `json:"name"`
+	ShortDescription sarifMessage  `json:"shortDescription"`
+	Help             *sarifMessage `json:"help,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifLevels maps gitleaks severities to SARIF result levels
+var sarifLevels = map[string]string{
+	"low":      "note",
+	"medium":   "warning",
+	"high":     "error",
+	"critical": "error",
+}
+
+// writeSARIF encodes leaks as a SARIF 2.1.0 log with a result for each leak. Offenders are never
+// written to the log so the report does not itself leak secrets. Results are located by file and,
+// if the leak's line is known, by line.
+func writeSARIF(w io.Writer, leaks []Leak) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "gitleaks",
+				InformationURI: "https://github.com/zricethezav/gitleaks",
+				Version:        version.Version,
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+	}
+
+	ruleSeen := make(map[string]bool)
+	for _, leak := range leaks {
+		if !ruleSeen[leak.Rule] {
+			ruleSeen[leak.Rule] = true
+			rule := sarifRule{
+				ID:               leak.Rule,
+				Name:             leak.Rule,
+				ShortDescription: sarifMessage{Text: leak.Rule},
+			}
+			if leak.Remediation != "" {
+				rule.Help = &sarifMessage{Text: leak.Remediation}
+			}
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+		}
+
+		level, ok := sarifLevels[leak.Severity]
+		if !ok {
+			level = "warning"
+		}
+		text, uri := fmt.Sprintf("%s detected in commit %s", leak.Rule, leak.Commit), leak.File
+		if leak.ArchiveEntry != "" {
+			text, uri = fmt.Sprintf("%s detected in archive entry %s", leak.Rule, leak.ArchiveEntry), leak.ArchiveEntry
+		}
+		result := sarifResult{
+			RuleID:  leak.Rule,
+			Level:   level,
+			Message: sarifMessage{Text: text},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: uri},
+				},
+			}},
+		}
+		if leak.LineNumber > 0 {
+			result.Locations[0].PhysicalLocation.Region = &sarifRegion{StartLine: leak.LineNumber}
+		}
+		if leak.Fingerprint != "" {
+			result.PartialFingerprints = map[string]string{"gitleaks/v1": leak.Fingerprint}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", " ")
+	return encoder.Encode(sarifReport{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	})
+}
@@ -0,0 +1,92 @@
+package manager
+
+import (
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// minScrubLen is the length below which offenders are not scrubbed from logs. Shorter offenders are
+// too likely to be common words that would mask unrelated parts of log lines.
+const minScrubLen = 6
+
+// scrubber masks the offenders of leaks found so far wherever they appear in log lines and
+// --debug-diff dumps. It is a safety net for secrets echoed in logs, like lines logged while
+// debugging or errors that quote content, and applies whether or not leaks are redacted.
+type scrubber struct {
+	mux       sync.RWMutex
+	offenders map[string]string
+}
+
+// logScrubber is shared by all managers since the logger is global. It is installed as a logrus
+// hook so every log line is scrubbed before it is written.
+var logScrubber = &scrubber{}
+
+func init() {
+	log.AddHook(logScrubber)
+}
+
+// add records offender so it is masked in later log lines
+func (s *scrubber) add(offender string) {
+	if len(offender) < minScrubLen {
+		return
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.offenders == nil {
+		s.offenders = make(map[string]string)
+	}
+	s.offenders[offender] = strings.Repeat("*", len(offender))
+}
+
+// offenderIsSecret returns false for leaks whose offender is not the secret found, like the path of a
+// file matching the config's file regex or the entropy range an entropy leak tripped, so they are not
+// masked in logs
+func offenderIsSecret(leak Leak) bool {
+	if leak.Line == "N/A" {
+		return false
+	}
+	return leak.Line == "" || strings.Contains(leak.Line, leak.Offender)
+}
+
+// scrub returns text with every recorded offender replaced by asterisks
+func (s *scrubber) scrub(text string) string {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	for offender, masked := range s.offenders {
+		text = strings.Replace(text, offender, masked, -1)
+	}
+	return text
+}
+
+// Levels implements logrus.Hook, every level is scrubbed
+func (s *scrubber) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire implements logrus.Hook by scrubbing the message and string fields of entry. Fields are
+// copied since they can be shared with the entry they were added to.
+func (s *scrubber) Fire(entry *log.Entry) error {
+	entry.Message = s.scrub(entry.Message)
+	data := make(log.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		switch v := v.(type) {
+		case string:
+			data[k] = s.scrub(v)
+		case error:
+			data[k] = s.scrub(v.Error())
+		default:
+			data[k] = v
+		}
+	}
+	entry.Data = data
+	return nil
+}
+
+// Scrub returns text with the offenders of every leak found so far masked with asterisks. It is
+// applied to log lines automatically and is used for other diagnostic output, like --debug-diff
+// dumps, that is written outside of the logger.
+func (manager *Manager) Scrub(text string) string {
+	return logScrubber.scrub(text)
+}
@@ -0,0 +1,97 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// fileRegexRule is the start of the rule of leaks of files matching the config's file regex, it is
+// followed by the regex
+const fileRegexRule = "file regex matched"
+
+// sonarReport is a SonarQube generic issue import report, loaded with the sonar.externalIssuesReportPaths
+// analysis parameter. Only the fields gitleaks populates are defined.
+type sonarReport struct {
+	Issues []sonarIssue `json:"issues"`
+}
+
+type sonarIssue struct {
+	EngineID        string        `json:"engineId"`
+	RuleID          string        `json:"ruleId"`
+	Severity        string        `json:"severity"`
+	Type            string        `json:"type"`
+	PrimaryLocation sonarLocation `json:"primaryLocation"`
+}
+
+type sonarLocation struct {
+	Message   string          `json:"message"`
+	FilePath  string          `json:"filePath"`
+	TextRange *sonarTextRange `json:"textRange,omitempty"`
+}
+
+type sonarTextRange struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+}
+
+// sonarSeverities maps gitleaks severities to SonarQube issue severities. Leaks of rules without a
+// severity are MAJOR, Sonar's default, so INFO is never used for a leaked secret.
+var sonarSeverities = map[string]string{
+	"low":      "MINOR",
+	"medium":   "MAJOR",
+	"high":     "CRITICAL",
+	"critical": "BLOCKER",
+}
+
+// writeSonar encodes leaks as a SonarQube generic issue report with a vulnerability for each leak.
+// Issue messages include the line the leak was found on with its offender redacted, offenders are
+// never written otherwise, and the leak's fingerprint since the format has no field for it. Messages
+// only name the commit of leaks found in one. Issues are located by file and, if the leak's line is
+// known, by line.
+func writeSonar(w io.Writer, leaks []Leak) error {
+	report := sonarReport{Issues: []sonarIssue{}}
+	for _, leak := range leaks {
+		severity, ok := sonarSeverities[leak.Severity]
+		if !ok {
+			severity = "MAJOR"
+		}
+		redacted := redactLeak(leak, false)
+		message, path := leak.Rule+" detected", leak.File
+		// leaks of file regexes are files that should not be committed, they have no line
+		if leak.Line == "N/A" && strings.HasPrefix(leak.Rule, fileRegexRule) {
+			message = "File matches the config's file regex " + strings.TrimPrefix(leak.Rule, fileRegexRule)
+		}
+		switch {
+		case leak.ArchiveEntry != "":
+			message, path = fmt.Sprintf("%s in archive entry %s", message, leak.ArchiveEntry), leak.ArchiveEntry
+		case leak.Commit != "":
+			message += " in commit " + leak.Commit
+		}
+		if redacted.Line != "" && redacted.Line != "N/A" {
+			message += ": " + redacted.Line
+		}
+		if leak.Fingerprint != "" {
+			message += " (fingerprint " + leak.Fingerprint + ")"
+		}
+		issue := sonarIssue{
+			EngineID: "gitleaks",
+			RuleID:   leak.Rule,
+			Severity: severity,
+			Type:     "VULNERABILITY",
+			PrimaryLocation: sonarLocation{
+				Message:  message,
+				FilePath: path,
+			},
+		}
+		if leak.LineNumber > 0 {
+			issue.PrimaryLocation.TextRange = &sonarTextRange{StartLine: leak.LineNumber, EndLine: leak.LineNumber}
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", " ")
+	return encoder.Encode(report)
+}
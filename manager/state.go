@@ -0,0 +1,60 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// auditState is the --state-file. It maps each repo to the commit at the tip of each of its
+// branches when the repo was last audited.
+type auditState map[string]map[string]string
+
+// loadState reads a --state-file. A state file that does not exist yet is treated as empty so the
+// first run audits every repo's full history and creates it.
+func loadState(path string) (auditState, error) {
+	state := make(auditState)
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, fmt.Errorf("problem loading state file %s: %v", path, err)
+	}
+	return state, nil
+}
+
+// AuditedTips returns the commit at the tip of each of repoName's branches, by branch, when the
+// repo was last audited. It is empty if the repo has not been audited with --state-file before.
+func (manager *Manager) AuditedTips(repoName string) map[string]string {
+	manager.stateMux.Lock()
+	defer manager.stateMux.Unlock()
+	tips := make(map[string]string)
+	for branch, commit := range manager.state[repoName] {
+		tips[branch] = commit
+	}
+	return tips
+}
+
+// RecordTips records the tips of repoName's branches once the repo has been fully audited. They
+// are written to the --state-file by SaveState.
+func (manager *Manager) RecordTips(repoName string, tips map[string]string) {
+	manager.stateMux.Lock()
+	defer manager.stateMux.Unlock()
+	manager.state[repoName] = tips
+}
+
+// SaveState writes the branch tips of the repos audited to the --state-file so the next run only
+// audits commits made since. It should only be called once the audit has succeeded. Repos that were
+// not audited this run keep the tips recorded by earlier runs.
+func (manager *Manager) SaveState() error {
+	if manager.Opts.StateFile == "" {
+		return nil
+	}
+	manager.stateMux.Lock()
+	defer manager.stateMux.Unlock()
+	return writeJSONAtomic(manager.Opts.StateFile, manager.state)
+}
@@ -0,0 +1,79 @@
+package manager
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// leakStream writes leaks to the --report file as newline delimited json as they are found when
+// --stream is set. Streamed leaks are not kept by the manager so memory does not grow with the
+// number of leaks.
+type leakStream struct {
+	mux  sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+	// gz compresses the leaks written to file when the report is gzipped. It is nil otherwise.
+	gz *gzip.Writer
+
+	// count is how many leaks have been written
+	count int
+}
+
+// openStream creates the report at path for streaming leaks to, compressed with gzip if compress is set
+func openStream(path string, compress bool) (*leakStream, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if !compress {
+		return &leakStream{file: file, w: bufio.NewWriter(file)}, nil
+	}
+	gz := gzip.NewWriter(file)
+	return &leakStream{file: file, w: bufio.NewWriter(gz), gz: gz}, nil
+}
+
+// write writes leak as a line of json. Leaks that can not be written are logged rather than failing
+// the audit.
+func (stream *leakStream) write(leak Leak) {
+	b, err := json.Marshal(leak)
+	if err != nil {
+		log.Errorf("could not encode leak: %v", err)
+		return
+	}
+	stream.mux.Lock()
+	defer stream.mux.Unlock()
+	if _, err := stream.w.Write(append(b, '\n')); err != nil {
+		log.Errorf("could not stream leak to report: %v", err)
+		return
+	}
+	stream.count++
+}
+
+// written returns how many leaks have been written
+func (stream *leakStream) written() int {
+	stream.mux.Lock()
+	defer stream.mux.Unlock()
+	return stream.count
+}
+
+// close flushes the leaks written and closes the report
+func (stream *leakStream) close() error {
+	stream.mux.Lock()
+	defer stream.mux.Unlock()
+	if err := stream.w.Flush(); err != nil {
+		stream.file.Close()
+		return err
+	}
+	if stream.gz != nil {
+		if err := stream.gz.Close(); err != nil {
+			stream.file.Close()
+			return err
+		}
+	}
+	return stream.file.Close()
+}
@@ -0,0 +1,32 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"text/template"
+)
+
+// loadReportTemplate parses the text/template file set by --report-template. It is parsed when the
+// manager is created so a broken template fails the run before any repos are audited.
+func loadReportTemplate(path string) (*template.Template, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("problem loading report template: %v", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Parse(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("problem loading report template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// writeTemplate renders leaks with the report template. The template's data is the []Leak slice
+// so templates range over it and use Leak's fields, e.g. {{range .}}{{.File}}{{end}}.
+func (manager *Manager) writeTemplate(w io.Writer, leaks []Leak) error {
+	if leaks == nil {
+		leaks = []Leak{}
+	}
+	return manager.reportTemplate.Execute(w, leaks)
+}
@@ -0,0 +1,87 @@
+package manager
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zricethezav/gitleaks/v3/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The statuses a leak's secret can be given by --validate
+const (
+	ValidationActive   = "active"
+	ValidationInactive = "inactive"
+	ValidationUnknown  = "unknown"
+)
+
+// validateClient is the client validate probes are sent with
+var validateClient = &http.Client{Timeout: 10 * time.Second}
+
+// validation is the status of a secret probed by --validate. Leaks of the same secret found
+// concurrently wait on once for the first probe rather than probing again.
+type validation struct {
+	once   sync.Once
+	status string
+}
+
+// ValidateSecret probes whether secret, found by rule, is active with the rule's validate probe and
+// returns its status. Each secret is only probed once per rule. Secrets are never logged, failed
+// probes are logged with the secret redacted.
+func (manager *Manager) ValidateSecret(rule, secret string, v *config.Validation) string {
+	key := rule + "\x00" + secret
+	manager.validationMux.Lock()
+	result, ok := manager.validations[key]
+	if !ok {
+		result = &validation{}
+		manager.validations[key] = result
+	}
+	manager.validationMux.Unlock()
+
+	result.once.Do(func() {
+		var err error
+		result.status, err = probe(secret, v)
+		if err != nil {
+			// request errors quote the url, which has the secret query escaped
+			msg := strings.Replace(err.Error(), url.QueryEscape(secret), "REDACTED", -1)
+			log.Warnf("could not validate secret found by rule %s: %s", rule,
+				strings.Replace(msg, secret, "REDACTED", -1))
+		}
+	})
+	return result.status
+}
+
+// probe sends v's request for secret and maps the response's status code to the secret's status
+func probe(secret string, v *config.Validation) (string, error) {
+	reqURL := strings.Replace(v.URL, config.SecretPlaceholder, url.QueryEscape(secret), -1)
+	req, err := http.NewRequest(v.Method, reqURL, nil)
+	if err != nil {
+		// the url is left out since it contains the secret
+		return ValidationUnknown, err
+	}
+	for name, value := range v.Headers {
+		req.Header.Set(name, strings.Replace(value, config.SecretPlaceholder, secret, -1))
+	}
+	resp, err := validateClient.Do(req)
+	if err != nil {
+		return ValidationUnknown, err
+	}
+	resp.Body.Close()
+
+	for _, code := range v.Active {
+		if resp.StatusCode == code {
+			return ValidationActive, nil
+		}
+	}
+	for _, code := range v.Inactive {
+		if resp.StatusCode == code {
+			return ValidationInactive, nil
+		}
+	}
+	log.Debugf("validate probe responded %d, which is neither an active nor an inactive status", resp.StatusCode)
+	return ValidationUnknown, nil
+}
@@ -0,0 +1,167 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// webhookTimeout bounds how long posting to --webhook-url can hold up the end of an audit
+	webhookTimeout = 10 * time.Second
+
+	// webhookTopRules and webhookSamples are how many rules and leaks a webhook summary lists
+	webhookTopRules = 5
+	webhookSamples  = 5
+)
+
+// webhookSummary is the json body posted to --webhook-url
+type webhookSummary struct {
+	Repos    []string        `json:"repos"`
+	Leaks    int             `json:"leaks"`
+	TopRules []webhookRule   `json:"topRules"`
+	Samples  []webhookSample `json:"samples"`
+}
+
+type webhookRule struct {
+	Rule  string `json:"rule"`
+	Leaks int    `json:"leaks"`
+}
+
+type webhookSample struct {
+	Repo       string `json:"repo"`
+	Rule       string `json:"rule"`
+	File       string `json:"file"`
+	LineNumber int    `json:"lineNumber,omitempty"`
+	Commit     string `json:"commit,omitempty"`
+	Line       string `json:"line"`
+}
+
+// slackMessage is a Slack incoming webhook message. Text is shown in notifications and blocks in
+// the channel.
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type string     `json:"type"`
+	Text *slackText `json:"text,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Notify posts a summary of the audit's leaks to --webhook-url if leaks were found. The summary is
+// shaped by --webhook-format. Webhooks are notifications, so failing to deliver one is logged rather
+// than failing the audit.
+func (manager *Manager) Notify() {
+	leaks := manager.GetLeaks()
+	if manager.Opts.WebhookURL == "" || len(leaks) == 0 {
+		return
+	}
+	summary := newWebhookSummary(leaks)
+	var payload interface{} = summary
+	if manager.Opts.WebhookFormat == "slack" {
+		payload = summary.slack()
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Warnf("could not encode webhook: %v", err)
+		return
+	}
+
+	client := http.Client{Timeout: webhookTimeout}
+	resp, err := client.Post(manager.Opts.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Warnf("could not post leaks to webhook: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		log.Warnf("could not post leaks to webhook: %s", resp.Status)
+	}
+}
+
+// newWebhookSummary summarizes leaks. Webhooks post to chat and other services outside the audit
+// so samples are always redacted, whether or not --redact is set.
+func newWebhookSummary(leaks []Leak) webhookSummary {
+	summary := webhookSummary{Leaks: len(leaks)}
+	repos := make(map[string]bool)
+	rules := make(map[string]int)
+	for _, leak := range leaks {
+		if !repos[leak.Repo] {
+			repos[leak.Repo] = true
+			summary.Repos = append(summary.Repos, leak.Repo)
+		}
+		rules[leak.Rule]++
+	}
+	sort.Strings(summary.Repos)
+
+	for rule, count := range rules {
+		summary.TopRules = append(summary.TopRules, webhookRule{Rule: rule, Leaks: count})
+	}
+	sort.Slice(summary.TopRules, func(i, j int) bool {
+		if summary.TopRules[i].Leaks != summary.TopRules[j].Leaks {
+			return summary.TopRules[i].Leaks > summary.TopRules[j].Leaks
+		}
+		return summary.TopRules[i].Rule < summary.TopRules[j].Rule
+	})
+	if len(summary.TopRules) > webhookTopRules {
+		summary.TopRules = summary.TopRules[:webhookTopRules]
+	}
+
+	for i := 0; i < len(leaks) && i < webhookSamples; i++ {
+		leak := redactLeak(leaks[i], false)
+		summary.Samples = append(summary.Samples, webhookSample{
+			Repo:       leak.Repo,
+			Rule:       leak.Rule,
+			File:       leak.File,
+			LineNumber: leak.LineNumber,
+			Commit:     leak.Commit,
+			Line:       leak.Line,
+		})
+	}
+	return summary
+}
+
+// slack shapes the summary as a Slack message with a section each for the count, top rules, and samples
+func (summary webhookSummary) slack() slackMessage {
+	text := fmt.Sprintf("gitleaks found %d leaks in %s", summary.Leaks, strings.Join(summary.Repos, ", "))
+
+	var rules strings.Builder
+	rules.WriteString("*Top rules*")
+	for _, rule := range summary.TopRules {
+		fmt.Fprintf(&rules, "\n• %s: %d", rule.Rule, rule.Leaks)
+	}
+
+	var samples strings.Builder
+	samples.WriteString("*Samples*")
+	for _, sample := range summary.Samples {
+		location := sample.File
+		if sample.LineNumber > 0 {
+			location = fmt.Sprintf("%s:%d", location, sample.LineNumber)
+		}
+		if sample.Commit != "" {
+			location += " in " + sample.Commit
+		}
+		fmt.Fprintf(&samples, "\n• %s `%s` in %s", sample.Rule, sample.Line, location)
+	}
+
+	return slackMessage{
+		Text: text,
+		Blocks: []slackBlock{
+			{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "*" + text + "*"}},
+			{Type: "section", Text: &slackText{Type: "mrkdwn", Text: rules.String()}},
+			{Type: "section", Text: &slackText{Type: "mrkdwn", Text: samples.String()}},
+		},
+	}
+}
@@ -5,7 +5,11 @@ import (
 	"io/ioutil"
 	"os"
 	"os/user"
+	"path"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/zricethezav/gitleaks/v3/version"
 
@@ -27,40 +31,120 @@ const (
 
 // Options stores values of command line options
 type Options struct {
-	Verbose       bool   `short:"v" long:"verbose" description:"Show verbose output from audit"`
-	Repo          string `short:"r" long:"repo" description:"Target repository"`
-	Config        string `long:"config" description:"config path"`
-	Disk          bool   `long:"disk" description:"Clones repo(s) to disk"`
-	Version       bool   `long:"version" description:"version number"`
-	Username      string `long:"username" description:"Username for git repo"`
-	Password      string `long:"password" description:"Password for git repo"`
-	AccessToken   string `long:"access-token" description:"Access token for git repo"`
-	Commit        string `long:"commit" description:"sha of commit to audit"`
-	FilesAtCommit string `long:"files-at-commit" description:"sha of commit to audit all files at commit"`
-	Threads       int    `long:"threads" description:"Maximum number of threads gitleaks spawns"`
-	SSH           string `long:"ssh-key" description:"path to ssh key used for auth"`
-	Uncommited    bool   `long:"uncommitted" description:"run gitleaks on uncommitted code"`
-	RepoPath      string `long:"repo-path" description:"Path to repo"`
-	OwnerPath     string `long:"owner-path" description:"Path to owner directory (repos discovered)"`
-	Branch        string `long:"branch" description:"Branch to audit"`
-	Report        string `long:"report" description:"path to write json leaks file"`
-	ReportFormat  string `long:"report-format" default:"json" description:"json or csv"`
-	Redact        bool   `long:"redact" description:"redact secrets from log messages and leaks"`
-	Debug         bool   `long:"debug" description:"log debug messages"`
-	RepoConfig    bool   `long:"repo-config" description:"Load config from target repo. Config file must be \".gitleaks.toml\" or \"gitleaks.toml\""`
-	PrettyPrint   bool   `long:"pretty" description:"Pretty print json if leaks are present"`
-	CommitFrom    string `long:"commit-from" description:"Commit to start audit from"`
-	CommitTo      string `long:"commit-to" description:"Commit to stop audit"`
-	Timeout       string `long:"timeout" description:"Time allowed per audit. Ex: 10us, 30s, 1m, 1h10m1s"`
-	Depth         int    `long:"depth" description:"Number of commits to audit"`
+	Verbose             bool     `short:"v" long:"verbose" description:"Show verbose output from audit"`
+	Repo                string   `short:"r" long:"repo" description:"Target repository"`
+	Config              string   `long:"config" description:"config path. Comma separated configs are merged in order, later rules replace earlier rules with the same description"`
+	Disk                bool     `long:"disk" description:"Clones repo(s) to disk"`
+	Version             bool     `long:"version" description:"version number"`
+	Username            string   `long:"username" description:"Username for git repo"`
+	Password            string   `long:"password" description:"Password for git repo"`
+	AccessToken         string   `long:"access-token" description:"Access token for git repo"`
+	Commit              string   `long:"commit" description:"sha of commit to audit"`
+	FilesAtCommit       string   `long:"files-at-commit" description:"sha of commit to audit all files at commit"`
+	Threads             int      `long:"threads" description:"Maximum number of threads gitleaks spawns"`
+	SSH                 string   `long:"ssh-key" description:"path to ssh key used for auth"`
+	Uncommited          bool     `long:"uncommitted" description:"run gitleaks on uncommitted code"`
+	Staged              bool     `long:"staged" description:"run gitleaks on staged changes only, like a pre-commit hook. Takes precedence over --uncommitted"`
+	WorkTree            bool     `long:"work-tree" description:"Audit the files tracked in the repo's index as they are on disk, ignoring history"`
+	RepoPath            string   `long:"repo-path" description:"Path to repo"`
+	OwnerPath           string   `long:"owner-path" description:"Path to owner directory (repos discovered)"`
+	RepoInclude         string   `long:"repo-include" description:"Only audit repos in owner-path whose directory name matches this glob"`
+	RepoExclude         string   `long:"repo-exclude" description:"Do not audit repos in owner-path whose directory name matches this glob"`
+	Submodules          bool     `long:"submodules" description:"Also audit the repo's initialized submodules. Leaks are reported with the submodule's path"`
+	ArchivePath         string   `long:"archive-path" description:"Path to a .tar, .tar.gz, .tgz, .zip, or .jar archive to audit without a git repo"`
+	ArchiveDepth        int      `long:"archive-depth" default:"2" description:"Maximum depth of archives nested in --archive-path to audit"`
+	PipeStdin           bool     `long:"pipe" description:"Audit the added lines of a unified diff read from stdin. No repo is needed"`
+	FilePaths           string   `long:"file-paths" description:"Comma separated list of files to audit without a git repo"`
+	ScanDir             string   `long:"scan-dir" description:"Directory to audit recursively without a git repo"`
+	PathRegex           string   `long:"path-regex" description:"Only audit files whose path matches this regex, like (^|/)config/. Other files are skipped rather than whitelisted"`
+	NoDefaultExcludes   bool     `long:"no-default-excludes" description:"Also audit files under .git, node_modules, and vendor directories with scan-dir and uncommitted, which are skipped by default"`
+	Branch              string   `long:"branch" description:"Branch to audit. Several branches can be audited at once as a comma separated list"`
+	BaseBranch          string   `long:"base-branch" description:"Only audit commits on the audited branch (--branch or HEAD) since its merge-base with this branch"`
+	Tags                bool     `long:"tags" description:"Also audit commits only reachable from tags. Leaks found in them are reported with the tag"`
+	Stash               bool     `long:"stash" description:"Also audit stashes, including older stashes in the stash reflog. Leaks found in them are reported with the stash"`
+	Notes               bool     `long:"notes" description:"Also audit git notes in refs/notes/*. Leaks found in them are reported with the annotated commit and the note's ref"`
+	Reflog              bool     `long:"reflog" description:"Also audit commits in the reflog that are no longer reachable from any ref, like commits amended or rebased away. Leaks found in them are reported as orphaned"`
+	ReflogSince         string   `long:"reflog-since" description:"Only audit reflog entries made after this date. Either RFC3339, like 2020-01-02T15:04:05Z, or days ago, like 30d"`
+	Report              string   `long:"report" description:"path to write json leaks file"`
+	ReportFormat        string   `long:"report-format" default:"json" description:"json, csv, sarif, junit, or sonar"`
+	ReportTemplate      string   `long:"report-template" description:"Path to a go text/template file used to write the report instead of report-format"`
+	ReportEnvelope      bool     `long:"report-envelope" description:"Wrap json reports in an object with a summary of the audit, {\"scan\": {...}, \"leaks\": [...]}"`
+	Gzip                bool     `long:"gzip" description:"Compress the report with gzip. Set by default when --report ends in .gz"`
+	Stream              bool     `long:"stream" description:"Write leaks to the report as newline delimited json as they are found instead of at the end of the audit"`
+	MergeReports        string   `long:"merge-reports" description:"Comma separated list of json reports, like those of audits run in parallel, to merge into --report instead of auditing. Leaks in several reports are kept once"`
+	Redact              bool     `long:"redact" description:"redact secrets from log messages and leaks"`
+	RedactPartial       bool     `long:"redact-partial" description:"redact secrets from log messages and leaks, keeping their first and last two characters"`
+	AnonymizePaths      bool     `long:"anonymize-paths" description:"Replace repo and file paths in the report with hashed tokens. The tokens and paths are written to <report>.paths.json"`
+	Context             int      `long:"context" description:"Number of lines before and after each leak's line to include in the report"`
+	Progress            bool     `long:"progress" description:"Print the number of commits audited out of the total to stderr. Redrawn as a bar on terminals, printed every 10% otherwise"`
+	Debug               bool     `long:"debug" description:"log debug messages"`
+	DebugDiff           bool     `long:"debug-diff" description:"Dump the lines of each commit inspected, prefixed with + when added and - when removed, and the rules tested, to stderr to debug rules that do not match. Only secrets already found are masked"`
+	NoColor             bool     `long:"no-color" description:"Disable colored log output. Logs are only colored when stdout is a terminal"`
+	LogFormat           string   `long:"log-format" default:"text" description:"text or json. json logs each line as an object with time, level, and msg"`
+	RepoConfig          bool     `long:"repo-config" description:"Load config from target repo. Config file must be \".gitleaks.toml\" or \"gitleaks.toml\""`
+	PrettyPrint         bool     `long:"pretty" description:"Pretty print json if leaks are present"`
+	CommitFrom          string   `long:"commit-from" description:"Commit to start audit from"`
+	CommitTo            string   `long:"commit-to" description:"Commit to stop audit"`
+	Revision            string   `long:"revision" description:"Only audit the commits of a git revision range, like origin/main..HEAD or HEAD~20..HEAD"`
+	Since               string   `long:"since" description:"Only audit commits authored after this date. Either RFC3339, like 2020-01-02T15:04:05Z, or days ago, like 30d"`
+	StateFile           string   `long:"state-file" description:"File recording the tip of each audited branch. Later audits only audit commits made since. Created if it does not exist"`
+	Timeout             string   `long:"timeout" description:"Time allowed per audit. Ex: 10us, 30s, 1m, 1h10m1s"`
+	CommitTimeout       string   `long:"commit-timeout" description:"Time allowed per commit. Commits exceeding it are skipped and reported. Ex: 10us, 30s, 1m"`
+	Depth               int      `long:"depth" description:"Number of commits to audit"`
+	MaxLeaks            int      `long:"max-leaks" description:"Stop auditing once this many leaks are found. The report is marked truncated"`
+	TraversalOrder      string   `long:"traversal-order" default:"date" description:"Order commits are walked in: date or topo. topo walks parents before children"`
+	ExitCodeLeak        int      `long:"exit-code-leak" default:"1" description:"Exit code when leaks are found, 0 is the same as exit-zero. Clean audits exit with 0 and errors with 2"`
+	ExitZero            bool     `long:"exit-zero" description:"Exit with code 0 even if leaks are present. Takes precedence over exit-code-leak"`
+	WebhookURL          string   `long:"webhook-url" description:"URL to POST a summary of leaks to when leaks are found. Secrets are always redacted"`
+	WebhookFormat       string   `long:"webhook-format" default:"json" description:"json or slack"`
+	CountOnly           bool     `long:"count-only" description:"Print the number of leaks to stderr instead of writing a report"`
+	GithubActions       bool     `long:"github-actions" description:"Print a GitHub Actions error annotation to stdout for each leak, with secrets redacted, so leaks are shown on pull request diffs"`
+	KnownSecretsFile    string   `long:"known-secrets" description:"Path to file of known secrets to hunt for, one per line. Lines may be sha256:<digest>"`
+	LFS                 bool     `long:"lfs" description:"Audit git lfs objects available locally instead of lfs pointer files"`
+	ScanBinary          bool     `long:"scan-binary" description:"Audit binary files. By default files with a NUL byte in their first 8000 bytes are skipped"`
+	DefaultEncoding     string   `long:"default-encoding" default:"utf-8" description:"Encoding of files on disk or in archives without a byte order mark: utf-8, utf-16le, utf-16be, or latin1"`
+	ScanMessages        bool     `long:"scan-messages" description:"Also audit the message of each commit. Leaks in messages have no file and a source of message"`
+	Baseline            string   `long:"baseline" description:"Path to a baseline json report of known leaks. Leaks in the baseline are not reported"`
+	BaselineUpdate      bool     `long:"baseline-update" description:"Merge accepted leaks from this audit into the baseline"`
+	BaselineAccept      string   `long:"baseline-accept" description:"Leaks to accept into the baseline on update. Either \"all\" or a path to a file of commit:file lines"`
+	FollowRenames       bool     `long:"follow-renames" description:"Follow file renames and report each secret once, attributed to the commit that introduced it"`
+	RenameThreshold     int      `long:"rename-threshold" default:"50" description:"Percentage of lines a deleted and an added file must share for --follow-renames to treat them as a rename"`
+	DotenvMode          bool     `long:"dotenv" description:"Also check the KEY=VALUE lines of dotenv files, like .env or prod.env, for values that look like secrets. Leaks are reported with their key as context"`
+	Validate            bool     `long:"validate" description:"Probe leaks of rules with a validate table to check whether their secrets are active. Sends the secrets over the network"`
+	StringLiteralsOnly  bool     `long:"string-literals-only" description:"Only audit string literals in Go, Python, and JavaScript/TypeScript files. Other files are audited in full"`
+	Metadata            []string `long:"metadata" description:"key=value metadata to attach to the report, like a build number. Can be set multiple times"`
+	Resume              bool     `long:"resume" description:"Skip repos already audited by an interrupted --owner-path audit. Progress is kept in <report>.resume"`
+	FingerprintHash     string   `long:"fingerprint-hash" default:"sha256" description:"Hash algorithm used for leak fingerprints: sha1 or sha256"`
+	OwnerThreads        int      `long:"owner-threads" description:"Maximum number of repos audited concurrently when --owner-path is set"`
+	DedupSecrets        bool     `long:"dedup-secrets" description:"Report each secret once across all audited repos, listing every location it was found"`
+	SplitBySeverity     bool     `long:"split-by-severity" description:"Write a report per severity level, like report.critical.json, instead of a single report. Clean audits write empty reports"`
+	SkipEmptySeverities bool     `long:"skip-empty-severities" description:"Do not write reports for severity levels without leaks when --split-by-severity is set"`
+	MinSeverity         string   `long:"min-severity" description:"Only report leaks at or above this severity: low, medium, high, or critical"`
+	IncludeTags         string   `long:"include-tags" description:"Comma separated rule tags. Only rules with one of these tags are evaluated"`
+	ExcludeTags         string   `long:"exclude-tags" description:"Comma separated rule tags. Rules with one of these tags are not evaluated"`
+	FailOnTags          string   `long:"fail-on-tags" description:"Comma separated rule tags. Only leaks of rules with one of these tags exit with a leak code, other leaks are reported but exit with 0"`
+	EnableRules         string   `long:"enable-rules" description:"Comma separated rule descriptions. Rules disabled in the config with enabled = false are evaluated anyway"`
+	DisableRules        string   `long:"disable-rules" description:"Comma separated rule descriptions. These rules are not evaluated"`
+	ListRules           bool     `long:"list-rules" description:"Print the rules of the config, after layering and filtering, as json to stdout and exit without auditing"`
 
 	// Hosts
-	Host         string `long:"host" description:"git hosting service like gitlab or github. Supported hosts include: Github, Gitlab"`
+	Host         string `long:"host" description:"git hosting service like gitlab or github. Supported hosts include: Github, Gitlab, Bitbucket"`
 	BaseURL      string `long:"baseurl" description:"Base URL for API requests. Defaults to the public GitLab or GitHub API, but can be set to a domain endpoint to use with a self hosted server."`
 	Organization string `long:"org" description:"organization to audit"`
 	User         string `long:"user" description:"user to audit"`
 	PullRequest  string `long:"pr" description:"pull/merge request url"`
 	ExcludeForks bool   `long:"exclude-forks" description:"audit excludes forks"`
+	GithubOrg    string `long:"github-org" description:"GitHub organization to audit. Shorthand for --host=github --org, includes private repos the token can access"`
+	GithubToken  string `long:"github-token" description:"GitHub access token. Takes precedence over --access-token for GitHub audits"`
+	GitlabGroup  string `long:"gitlab-group" description:"GitLab group to audit, including the projects of its subgroups. Shorthand for --host=gitlab --org"`
+	GitlabToken  string `long:"gitlab-token" description:"GitLab access token. Takes precedence over --access-token for GitLab audits"`
+	GitlabURL    string `long:"gitlab-url" description:"URL of a self hosted GitLab instance. Takes precedence over --baseurl for GitLab audits"`
+	GitlabDepth  int    `long:"gitlab-depth" default:"5" description:"Maximum depth of subgroups audited by --gitlab-group"`
+
+	BitbucketURL     string `long:"bitbucket-url" description:"URL of a Bitbucket Server instance. Required by --bitbucket-project"`
+	BitbucketProject string `long:"bitbucket-project" description:"Key of a Bitbucket Server project to audit the repos of"`
+	BitbucketToken   string `long:"bitbucket-token" description:"Bitbucket Server personal access token. Takes precedence over --access-token for Bitbucket audits"`
+	BitbucketAPIPath string `long:"bitbucket-api-path" default:"/rest/api/1.0" description:"Path of the REST API under --bitbucket-url, for servers behind a proxy that moves it"`
 }
 
 // ParseOptions is responsible for parsing options passed in by cli. An Options struct
@@ -88,30 +172,273 @@ func ParseOptions() (Options, error) {
 		os.Exit(Success)
 	}
 
+	opts.zeroExitCode()
+
 	if opts.Debug {
 		log.SetLevel(log.DebugLevel)
 	}
+	switch {
+	case opts.LogFormat == "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	case opts.NoColor:
+		log.SetFormatter(&log.TextFormatter{
+			DisableColors: true,
+			FullTimestamp: true,
+		})
+	}
 
 	return opts, nil
 }
 
+// zeroExitCode makes --exit-code-leak=0 exit with 0 when leaks are found, like --exit-zero. The
+// option defaults to 1 when parsed so 0 was asked for, options built in code leave it 0 for the
+// default.
+func (opts *Options) zeroExitCode() {
+	if opts.ExitCodeLeak == 0 {
+		opts.ExitZero = true
+	}
+}
+
 // Guard checks to makes sure there are no invalid options set.
 // If invalid sets of options are present, a descriptive error will return
 // else nil is returned
 func (opts Options) Guard() error {
-	if !oneOrNoneSet(opts.Repo, opts.OwnerPath, opts.RepoPath, opts.Host) {
-		return fmt.Errorf("only one target option must can be set. target options: repo, owner-path, repo-path, host")
+	if !oneOrNoneSet(opts.Repo, opts.OwnerPath, opts.RepoPath, opts.Host, opts.ArchivePath, opts.GithubOrg, opts.GitlabGroup, opts.BitbucketProject, opts.FilePaths, opts.ScanDir) {
+		return fmt.Errorf("only one target option must can be set. target options: repo, owner-path, repo-path, host, archive-path, github-org, gitlab-group, bitbucket-project, file-paths, scan-dir")
 	}
 	if !oneOrNoneSet(opts.Organization, opts.User, opts.PullRequest) {
 		return fmt.Errorf("only one target option must can be set. target options: repo, owner-path, repo-path, host")
 	}
+	if opts.WorkTree && (opts.Repo != "" || opts.OwnerPath != "" || opts.Host != "" || opts.ArchivePath != "" ||
+		opts.GithubOrg != "" || opts.GitlabGroup != "" || opts.BitbucketProject != "" || opts.FilePaths != "" ||
+		opts.ScanDir != "" || opts.PipeStdin || opts.Uncommited || opts.Staged) {
+		return fmt.Errorf("work-tree audits a local checkout so it can only be used with repo-path or in the current directory")
+	}
+	if opts.PipeStdin && !oneOrNoneSet("pipe", opts.Repo, opts.OwnerPath, opts.RepoPath, opts.Host, opts.ArchivePath, opts.GithubOrg, opts.GitlabGroup, opts.BitbucketProject, opts.FilePaths, opts.ScanDir) {
+		return fmt.Errorf("pipe can not be combined with another target option")
+	}
+	if opts.GithubOrg != "" && (opts.User != "" || opts.PullRequest != "") {
+		return fmt.Errorf("github-org can not be combined with user or pr")
+	}
+	if opts.GitlabGroup != "" && (opts.User != "" || opts.PullRequest != "") {
+		return fmt.Errorf("gitlab-group can not be combined with user or pr")
+	}
+	if opts.BitbucketProject != "" && (opts.User != "" || opts.PullRequest != "") {
+		return fmt.Errorf("bitbucket-project can not be combined with user or pr")
+	}
+	if opts.BitbucketProject != "" && opts.BitbucketURL == "" {
+		return fmt.Errorf("bitbucket-project requires bitbucket-url to be set")
+	}
+	if opts.RenameThreshold < 0 || opts.RenameThreshold > 100 {
+		return fmt.Errorf("rename-threshold must be between 0 and 100")
+	}
+	if opts.ExitCodeLeak < 0 || opts.ExitCodeLeak > 255 || opts.ExitCodeLeak == ErrorEncountered {
+		return fmt.Errorf("exit-code-leak must be between 0 and 255 and not %d, the exit code for errors", ErrorEncountered)
+	}
+	if opts.GitlabDepth < 0 {
+		return fmt.Errorf("gitlab-depth must not be negative")
+	}
+	if opts.MaxLeaks < 0 {
+		return fmt.Errorf("max-leaks must not be negative")
+	}
+	if opts.Context < 0 {
+		return fmt.Errorf("context must not be negative")
+	}
+	if opts.DebugDiff {
+		log.Warn("debug-diff dumps every line inspected to stderr, lines are not redacted")
+	}
 	if !oneOrNoneSet(opts.AccessToken, opts.Password) {
 		log.Warn("both access-token and password are set. Only password will be attempted")
 	}
+	if opts.BaselineUpdate && (opts.Baseline == "" || opts.BaselineAccept == "") {
+		return fmt.Errorf("baseline-update requires both baseline and baseline-accept to be set")
+	}
+	if _, err := opts.ParseMetadata(); err != nil {
+		return err
+	}
+	disabled := make(map[string]bool)
+	for _, rule := range strings.Split(opts.DisableRules, ",") {
+		disabled[strings.TrimSpace(rule)] = true
+	}
+	for _, rule := range strings.Split(opts.EnableRules, ",") {
+		if rule = strings.TrimSpace(rule); rule != "" && disabled[rule] {
+			return fmt.Errorf("rule %s can not be both enabled and disabled", rule)
+		}
+	}
+	if opts.Resume && (opts.OwnerPath == "" || opts.Report == "") {
+		return fmt.Errorf("resume requires both owner-path and report to be set")
+	}
+	if opts.Resume && (opts.OwnerThreads > 1 || opts.DedupSecrets) {
+		return fmt.Errorf("resume can not be combined with owner-threads or dedup-secrets")
+	}
+	if _, err := path.Match(opts.RepoInclude, ""); err != nil {
+		return fmt.Errorf("repo-include is not a valid glob: %s", opts.RepoInclude)
+	}
+	if _, err := path.Match(opts.RepoExclude, ""); err != nil {
+		return fmt.Errorf("repo-exclude is not a valid glob: %s", opts.RepoExclude)
+	}
+	if opts.ArchiveDepth < 0 {
+		return fmt.Errorf("archive-depth must not be negative")
+	}
+	if opts.SplitBySeverity && opts.Report == "" {
+		return fmt.Errorf("split-by-severity requires report to be set")
+	}
+	if opts.ReportTemplate != "" && opts.Report == "" {
+		return fmt.Errorf("report-template requires report to be set")
+	}
+	if opts.ReportEnvelope && opts.ReportFormat != "json" {
+		return fmt.Errorf("report-envelope requires report-format json")
+	}
+	if opts.Stream {
+		switch {
+		case opts.Report == "":
+			return fmt.Errorf("stream requires report to be set")
+		case opts.ReportFormat != "json":
+			return fmt.Errorf("stream requires report-format json")
+		case opts.DedupSecrets || opts.FollowRenames:
+			return fmt.Errorf("stream can not be used with dedup-secrets or follow-renames, which update leaks after they are found")
+		case opts.SplitBySeverity || opts.ReportTemplate != "" || opts.ReportEnvelope || opts.CountOnly || opts.GithubActions ||
+			opts.BaselineUpdate || opts.Resume || opts.WebhookURL != "":
+			return fmt.Errorf("stream can not be used with options that need every leak at the end of the audit")
+		}
+	}
+	if opts.AnonymizePaths && opts.Report == "" {
+		return fmt.Errorf("anonymize-paths requires report to be set")
+	}
+	if opts.MergeReports != "" {
+		switch {
+		case opts.Report == "":
+			return fmt.Errorf("merge-reports requires report to be set")
+		case !oneOrNoneSet("merge", opts.Repo, opts.OwnerPath, opts.RepoPath, opts.Host, opts.ArchivePath, opts.GithubOrg,
+			opts.GitlabGroup, opts.BitbucketProject, opts.FilePaths, opts.ScanDir) || opts.PipeStdin:
+			return fmt.Errorf("merge-reports can not be combined with a target option since nothing is audited")
+		case opts.Stream || opts.Resume || opts.BaselineUpdate || opts.StateFile != "":
+			return fmt.Errorf("merge-reports can not be used with stream, resume, baseline-update, or state-file")
+		}
+	}
+	if opts.StateFile != "" && (opts.Commit != "" || opts.FilesAtCommit != "" || opts.CommitFrom != "" ||
+		opts.CommitTo != "" || opts.BaseBranch != "" || opts.Revision != "" || opts.Depth != 0) {
+		return fmt.Errorf("state-file audits every new commit so it can not be used with commit, files-at-commit, commit-from, commit-to, base-branch, revision, or depth")
+	}
+	if opts.Revision != "" && (opts.Commit != "" || opts.FilesAtCommit != "" || opts.CommitFrom != "" ||
+		opts.CommitTo != "" || opts.BaseBranch != "" || opts.Branch != "") {
+		return fmt.Errorf("revision selects the commits audited so it can not be used with commit, files-at-commit, commit-from, commit-to, base-branch, or branch")
+	}
+	if opts.BaseBranch != "" && len(opts.Branches()) > 1 {
+		return fmt.Errorf("base-branch can only be used with a single branch")
+	}
+	if _, err := opts.ParseSince(time.Now()); err != nil {
+		return err
+	}
+	if _, err := opts.ParseReflogSince(time.Now()); err != nil {
+		return err
+	}
+	if opts.ReflogSince != "" && !opts.Reflog {
+		return fmt.Errorf("reflog-since requires reflog to be set")
+	}
+	switch opts.WebhookFormat {
+	case "", "json", "slack":
+	default:
+		return fmt.Errorf("webhook-format must be json or slack, got %s", opts.WebhookFormat)
+	}
+	switch opts.LogFormat {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("log-format must be text or json, got %s", opts.LogFormat)
+	}
+	switch opts.TraversalOrder {
+	case "", "date", "topo":
+	default:
+		return fmt.Errorf("traversal-order must be date or topo, got %s", opts.TraversalOrder)
+	}
+	switch opts.MinSeverity {
+	case "", "low", "medium", "high", "critical":
+	default:
+		return fmt.Errorf("min-severity must be low, medium, high, or critical, got %s", opts.MinSeverity)
+	}
+	switch opts.FingerprintHash {
+	case "", "sha1", "sha256":
+	default:
+		return fmt.Errorf("fingerprint-hash must be sha1 or sha256, got %s", opts.FingerprintHash)
+	}
+	switch opts.DefaultEncoding {
+	case "", "utf-8", "utf-16le", "utf-16be", "latin1":
+	default:
+		return fmt.Errorf("default-encoding must be utf-8, utf-16le, utf-16be, or latin1, got %s", opts.DefaultEncoding)
+	}
 
 	return nil
 }
 
+// ParseMetadata parses the key=value pairs set by --metadata into a map. An error
+// is returned if any pair is malformed or a key is set more than once.
+func (opts Options) ParseMetadata() (map[string]string, error) {
+	if len(opts.Metadata) == 0 {
+		return nil, nil
+	}
+	metadata := make(map[string]string)
+	for _, kv := range opts.Metadata {
+		split := strings.SplitN(kv, "=", 2)
+		key := strings.TrimSpace(split[0])
+		if len(split) != 2 || key == "" {
+			return nil, fmt.Errorf("invalid metadata %q, must be in the form key=value", kv)
+		}
+		if _, ok := metadata[key]; ok {
+			return nil, fmt.Errorf("metadata key %q set more than once", key)
+		}
+		metadata[key] = split[1]
+	}
+	return metadata, nil
+}
+
+// ParseSince parses --since into the time commits must be authored after to be audited. Dates are
+// RFC3339 and relative dates are a number of days before now, like 30d. The zero time is returned if
+// --since is not set.
+func (opts Options) ParseSince(now time.Time) (time.Time, error) {
+	return parseDate("since", opts.Since, now)
+}
+
+// ParseReflogSince parses --reflog-since, the time reflog entries must be made after to be audited,
+// like ParseSince
+func (opts Options) ParseReflogSince(now time.Time) (time.Time, error) {
+	return parseDate("reflog-since", opts.ReflogSince, now)
+}
+
+// parseDate parses the date set by option, either RFC3339 or a number of days before now
+func parseDate(option, date string, now time.Time) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	if strings.HasSuffix(date, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(date, "d"))
+		if err != nil || days < 0 {
+			return time.Time{}, fmt.Errorf("%s must be an RFC3339 date or a number of days like 30d, got %s", option, date)
+		}
+		return now.AddDate(0, 0, -days), nil
+	}
+	parsed, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC3339 date or a number of days like 30d, got %s", option, date)
+	}
+	return parsed, nil
+}
+
+// ExitCode returns the code gitleaks exits with for an audit that found leaks or failed with err.
+// Errors exit with ErrorEncountered, leaks with --exit-code-leak, LeaksPresent if it is not set, and
+// clean audits, or any audit with --exit-zero, with Success. An --exit-code-leak of 0 is turned into
+// --exit-zero when options are parsed, so 0 here is always unset.
+func (opts Options) ExitCode(leaks int, err error) int {
+	switch {
+	case err != nil:
+		return ErrorEncountered
+	case leaks == 0 || opts.ExitZero:
+		return Success
+	case opts.ExitCodeLeak != 0:
+		return opts.ExitCodeLeak
+	}
+	return LeaksPresent
+}
+
 func oneOrNoneSet(optStr ...string) bool {
 	c := 0
 	for _, s := range optStr {
@@ -205,7 +532,7 @@ func SSHAuth(opts Options) (*ssh.PublicKeys, error) {
 // OpenLocal checks what options are set, if no remote targets are set
 // then return true
 func (opts Options) OpenLocal() bool {
-	if opts.Uncommited || opts.RepoPath != "" || opts.Repo == "" {
+	if opts.Uncommited || opts.Staged || opts.WorkTree || opts.RepoPath != "" || opts.Repo == "" {
 		return true
 	}
 	return false
@@ -215,10 +542,10 @@ func (opts Options) OpenLocal() bool {
 // or if gitleaks should check the entire git history
 func (opts Options) CheckUncommitted() bool {
 	// check to make sure no remote shit is set
-	if opts.Uncommited {
+	if opts.Uncommited || opts.Staged {
 		return true
 	}
-	if opts == (Options{}) {
+	if reflect.DeepEqual(opts, Options{}) {
 		return true
 	}
 	if opts.Repo != "" {
@@ -230,9 +557,89 @@ func (opts Options) CheckUncommitted() bool {
 	if opts.OwnerPath != "" {
 		return false
 	}
-	if opts.Host != "" {
+	if opts.AuditHost() {
+		return false
+	}
+	if opts.ArchivePath != "" {
+		return false
+	}
+	if opts.PipeStdin {
 		return false
 	}
+	if opts.AuditFilesystem() {
+		return false
+	}
+	return true
+}
+
+// Target returns what is being audited, like a repo url or path, for reports to record
+func (opts Options) Target() string {
+	for _, target := range []string{opts.Repo, opts.RepoPath, opts.OwnerPath, opts.ArchivePath, opts.ScanDir,
+		opts.FilePaths, opts.GithubOrg, opts.GitlabGroup, opts.BitbucketProject, opts.PullRequest, opts.Organization, opts.User} {
+		if target != "" {
+			return target
+		}
+	}
+	if opts.PipeStdin {
+		return "stdin"
+	}
+	return "."
+}
+
+// AuditHost returns true if the repos of a git hosting service are audited, set by host, github-org, gitlab-group,
+// or bitbucket-project
+func (opts Options) AuditHost() bool {
+	return opts.Host != "" || opts.GithubOrg != "" || opts.GitlabGroup != "" || opts.BitbucketProject != ""
+}
+
+// AuditFilesystem returns true if files on disk are audited without a git repo, set by file-paths or scan-dir
+func (opts Options) AuditFilesystem() bool {
+	return opts.FilePaths != "" || opts.ScanDir != ""
+}
+
+// Branches returns the branches set by --branch, a comma separated list
+func (opts Options) Branches() []string {
+	var branches []string
+	for _, branch := range strings.Split(opts.Branch, ",") {
+		if branch = strings.TrimSpace(branch); branch != "" {
+			branches = append(branches, branch)
+		}
+	}
+	return branches
+}
+
+// GzipReport returns true if the report should be compressed with gzip, when --gzip is set or the
+// report's path ends in .gz
+func (opts Options) GzipReport() bool {
+	return opts.Gzip || strings.HasSuffix(opts.Report, ".gz")
+}
+
+// MergeReportPaths returns the reports set by --merge-reports, a comma separated list
+func (opts Options) MergeReportPaths() []string {
+	var reports []string
+	for _, report := range strings.Split(opts.MergeReports, ",") {
+		if report = strings.TrimSpace(report); report != "" {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+// RepoSelected returns true if the repo directory name should be audited by an owner-path audit. If
+// repo-include is set only repos matching it are selected, then repos matching repo-exclude are removed.
+// Globs are matched case-insensitively.
+func (opts Options) RepoSelected(name string) bool {
+	name = strings.ToLower(name)
+	if opts.RepoInclude != "" {
+		if ok, _ := path.Match(strings.ToLower(opts.RepoInclude), name); !ok {
+			return false
+		}
+	}
+	if opts.RepoExclude != "" {
+		if ok, _ := path.Match(strings.ToLower(opts.RepoExclude), name); ok {
+			return false
+		}
+	}
 	return true
 }
 
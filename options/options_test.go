@@ -1 +1,264 @@
 package options
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+)
+
+func TestParseMetadata(t *testing.T) {
+	tests := []struct {
+		description string
+		metadata    []string
+		want        map[string]string
+		wantErr     error
+	}{
+		{
+			description: "no metadata",
+		},
+		{
+			description: "build metadata",
+			metadata:    []string{"build=1234", "branch=feature/x", "query=a=b"},
+			want:        map[string]string{"build": "1234", "branch": "feature/x", "query": "a=b"},
+		},
+		{
+			description: "missing value",
+			metadata:    []string{"build"},
+			wantErr:     fmt.Errorf("invalid metadata \"build\", must be in the form key=value"),
+		},
+		{
+			description: "missing key",
+			metadata:    []string{"=1234"},
+			wantErr:     fmt.Errorf("invalid metadata \"=1234\", must be in the form key=value"),
+		},
+		{
+			description: "duplicate key",
+			metadata:    []string{"build=1", "build=2"},
+			wantErr:     fmt.Errorf("metadata key \"build\" set more than once"),
+		},
+	}
+
+	for _, test := range tests {
+		opts := Options{Metadata: test.metadata}
+		got, err := opts.ParseMetadata()
+		if err != nil {
+			if test.wantErr == nil {
+				t.Errorf("%s: %v", test.description, err)
+			} else if test.wantErr.Error() != err.Error() {
+				t.Errorf("%s: expected err: %s, got %s", test.description, test.wantErr, err)
+			}
+			continue
+		}
+		if test.wantErr != nil {
+			t.Errorf("%s: did not receive wantErr: %v", test.description, test.wantErr)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.description, got, test.want)
+		}
+		if err := opts.Guard(); err != nil {
+			t.Errorf("%s: unexpected guard err: %v", test.description, err)
+		}
+	}
+}
+
+func TestRepoSelected(t *testing.T) {
+	tests := []struct {
+		description string
+		include     string
+		exclude     string
+		repo        string
+		want        bool
+	}{
+		{
+			description: "no filters",
+			repo:        "service",
+			want:        true,
+		},
+		{
+			description: "included",
+			include:     "api-*",
+			repo:        "api-users",
+			want:        true,
+		},
+		{
+			description: "not included",
+			include:     "api-*",
+			repo:        "web",
+		},
+		{
+			description: "excluded",
+			exclude:     "*-archive",
+			repo:        "billing-archive",
+		},
+		{
+			description: "included then excluded",
+			include:     "api-*",
+			exclude:     "*-archive",
+			repo:        "api-archive",
+		},
+		{
+			description: "case insensitive",
+			include:     "API-*",
+			exclude:     "*-ARCHIVE",
+			repo:        "Api-Users",
+			want:        true,
+		},
+	}
+	for _, test := range tests {
+		opts := Options{RepoInclude: test.include, RepoExclude: test.exclude}
+		if got := opts.RepoSelected(test.repo); got != test.want {
+			t.Errorf("%s: got %v, wanted %v", test.description, got, test.want)
+		}
+	}
+}
+
+func TestGuardLogFormat(t *testing.T) {
+	tests := []struct {
+		logFormat string
+		wantErr   string
+	}{
+		{logFormat: "text"},
+		{logFormat: "json"},
+		{logFormat: "xml", wantErr: "log-format must be text or json, got xml"},
+	}
+	for _, test := range tests {
+		err := Options{LogFormat: test.logFormat}.Guard()
+		if test.wantErr == "" && err != nil {
+			t.Errorf("log format %s: unexpected error %v", test.logFormat, err)
+		} else if test.wantErr != "" && (err == nil || err.Error() != test.wantErr) {
+			t.Errorf("log format %s: got error %v, wanted %s", test.logFormat, err, test.wantErr)
+		}
+	}
+}
+
+func TestGuardExitCodeLeak(t *testing.T) {
+	for code, wantErr := range map[int]bool{0: false, 1: false, 2: true, 3: false, 255: false, 256: true, -1: true} {
+		err := Options{ExitCodeLeak: code}.Guard()
+		if wantErr != (err != nil) {
+			t.Errorf("exit-code-leak %d: got error %v, wanted error %t", code, err, wantErr)
+		}
+	}
+	want := "exit-code-leak must be between 0 and 255 and not 2, the exit code for errors"
+	if err := (Options{ExitCodeLeak: -1}).Guard(); err == nil || err.Error() != want {
+		t.Errorf("got error %v, wanted %s", err, want)
+	}
+
+	// an explicit 0 exits with 0 when leaks are found, options left unset exit with the default
+	for _, test := range []struct {
+		args []string
+		want int
+	}{
+		{args: []string{"--exit-code-leak=0"}, want: Success},
+		{args: []string{"--exit-code-leak=3"}, want: 3},
+		{args: nil, want: LeaksPresent},
+	} {
+		var opts Options
+		if _, err := flags.ParseArgs(&opts, test.args); err != nil {
+			t.Fatal(err)
+		}
+		opts.zeroExitCode()
+		if err := opts.Guard(); err != nil {
+			t.Errorf("%v: got error %v", test.args, err)
+		}
+		if got := opts.ExitCode(1, nil); got != test.want {
+			t.Errorf("%v: got exit code %d, wanted %d", test.args, got, test.want)
+		}
+	}
+	if got := (Options{}).ExitCode(1, nil); got != LeaksPresent {
+		t.Errorf("got exit code %d for options built in code, wanted %d", got, LeaksPresent)
+	}
+}
+
+func TestBranches(t *testing.T) {
+	opts := Options{Branch: "master, release/1.0,,dev"}
+	if got, want := opts.Branches(), []string{"master", "release/1.0", "dev"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got branches %v, wanted %v", got, want)
+	}
+	opts.BaseBranch = "main"
+	if err := opts.Guard(); err == nil || err.Error() != "base-branch can only be used with a single branch" {
+		t.Errorf("got error %v, wanted base-branch to be rejected with several branches", err)
+	}
+}
+
+func TestGuardMergeReports(t *testing.T) {
+	tests := []struct {
+		opts    Options
+		wantErr string
+	}{
+		{opts: Options{MergeReports: "a.json,b.json", Report: "merged.json"}},
+		{opts: Options{MergeReports: "a.json,b.json"}, wantErr: "merge-reports requires report to be set"},
+		{
+			opts:    Options{MergeReports: "a.json,b.json", Report: "merged.json", RepoPath: "."},
+			wantErr: "merge-reports can not be combined with a target option since nothing is audited",
+		},
+		{
+			opts:    Options{MergeReports: "a.json,b.json", Report: "merged.json", ReportFormat: "json", Stream: true},
+			wantErr: "merge-reports can not be used with stream, resume, baseline-update, or state-file",
+		},
+	}
+	for _, test := range tests {
+		err := test.opts.Guard()
+		if test.wantErr == "" && err != nil {
+			t.Errorf("%+v: unexpected error %v", test.opts, err)
+		} else if test.wantErr != "" && (err == nil || err.Error() != test.wantErr) {
+			t.Errorf("%+v: got error %v, wanted %s", test.opts, err, test.wantErr)
+		}
+	}
+	if got, want := (Options{MergeReports: " a.json,, b.json"}).MergeReportPaths(), []string{"a.json", "b.json"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got reports %v, wanted %v", got, want)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2020, 3, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		since   string
+		want    time.Time
+		wantErr bool
+	}{
+		{},
+		{since: "2020-01-02T15:04:05Z", want: time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{since: "30d", want: time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{since: "0d", want: now},
+		{since: "-1d", wantErr: true},
+		{since: "2020-01-02", wantErr: true},
+		{since: "yesterday", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := Options{Since: test.since}.ParseSince(now)
+		if (err != nil) != test.wantErr {
+			t.Errorf("since %q: got error %v, wanted error %v", test.since, err, test.wantErr)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("since %q: got %s, wanted %s", test.since, got, test.want)
+		}
+	}
+}
+
+func TestGuardRevision(t *testing.T) {
+	if err := (Options{Revision: "origin/main..HEAD"}).Guard(); err != nil {
+		t.Errorf("got error %v, wanted revision to be accepted", err)
+	}
+	err := Options{Revision: "origin/main..HEAD", Branch: "dev"}.Guard()
+	if err == nil || !strings.Contains(err.Error(), "revision selects the commits audited") {
+		t.Errorf("got error %v, wanted revision to be rejected with branch", err)
+	}
+}
+
+func TestGuardWorkTree(t *testing.T) {
+	for _, opts := range []Options{{WorkTree: true}, {WorkTree: true, RepoPath: "."}} {
+		if err := opts.Guard(); err != nil {
+			t.Errorf("got error %v, wanted work-tree to be accepted with %+v", err, opts)
+		}
+	}
+	for _, opts := range []Options{{WorkTree: true, Repo: "https://github.com/gitleakstest/gronit"}, {WorkTree: true, Staged: true}} {
+		if err := opts.Guard(); err == nil || !strings.Contains(err.Error(), "work-tree audits a local checkout") {
+			t.Errorf("got error %v, wanted work-tree to be rejected", err)
+		}
+	}
+}
@@ -0,0 +1 @@
+!function(){function f0(a,b){return a.map(function(c){return c+b*0})};function f1(a,b){return a.map(function(c){return c+b*1})};function f2(a,b){return a.map(function(c){return c+b*2})};function f3(a,b){return a.map(function(c){return c+b*3})};function f4(a,b){return a.map(function(c){return c+b*4})};function f5(a,b){return a.map(function(c){return c+b*5})};function f6(a,b){return a.map(function(c){return c+b*6})};function f7(a,b){return a.map(function(c){return c+b*7})};function f8(a,b){return a.map(function(c){return c+b*8})};function f9(a,b){return a.map(function(c){return c+b*9})};function f10(a,b){return a.map(function(c){return c+b*10})};function f11(a,b){return a.map(function(c){return c+b*11})};function f12(a,b){return a.map(function(c){return c+b*12})};function f13(a,b){return a.map(function(c){return c+b*13})};function f14(a,b){return a.map(function(c){return c+b*14})};function f15(a,b){return a.map(function(c){return c+b*15})};function f16(a,b){return a.map(function(c){return c+b*16})};function f17(a,b){return a.map(function(c){return c+b*17})};function f18(a,b){return a.map(function(c){return c+b*18})};function f19(a,b){return a.map(function(c){return c+b*19})};function f20(a,b){return a.map(function(c){return c+b*20})};function f21(a,b){return a.map(function(c){return c+b*21})};function f22(a,b){return a.map(function(c){return c+b*22})};function f23(a,b){return a.map(function(c){return c+b*23})};function f24(a,b){return a.map(function(c){return c+b*24})};function f25(a,b){return a.map(function(c){return c+b*25})};function f26(a,b){return a.map(function(c){return c+b*26})};function f27(a,b){return a.map(function(c){return c+b*27})};function f28(a,b){return a.map(function(c){return c+b*28})};function f29(a,b){return a.map(function(c){return c+b*29})};function f30(a,b){return a.map(function(c){return c+b*30})};function f31(a,b){return a.map(function(c){return c+b*31})};function f32(a,b){return a.map(function(c){return c+b*32})};function f33(a,b){return a.map(function(c){return c+b*33})};function f34(a,b){return a.map(function(c){return c+b*34})};function f35(a,b){return a.map(function(c){return c+b*35})};function f36(a,b){return a.map(function(c){return c+b*36})};function f37(a,b){return a.map(function(c){return c+b*37})};function f38(a,b){return a.map(function(c){return c+b*38})};function f39(a,b){return a.map(function(c){return c+b*39})};function f40(a,b){return a.map(function(c){return c+b*40})};function f41(a,b){return a.map(function(c){return c+b*41})};function f42(a,b){return a.map(function(c){return c+b*42})};function f43(a,b){return a.map(function(c){return c+b*43})};function f44(a,b){return a.map(function(c){return c+b*44})};function f45(a,b){return a.map(function(c){return c+b*45})};function f46(a,b){return a.map(function(c){return c+b*46})};function f47(a,b){return a.map(function(c){return c+b*47})};function f48(a,b){return a.map(function(c){return c+b*48})};function f49(a,b){return a.map(function(c){return c+b*49})};function f50(a,b){return a.map(function(c){return c+b*50})};function f51(a,b){return a.map(function(c){return c+b*51})};function f52(a,b){return a.map(function(c){return c+b*52})};function f53(a,b){return a.map(function(c){return c+b*53})};function f54(a,b){return a.map(function(c){return c+b*54})};function f55(a,b){return a.map(function(c){return c+b*55})};function f56(a,b){return a.map(function(c){return c+b*56})};function f57(a,b){return a.map(function(c){return c+b*57})};function f58(a,b){return a.map(function(c){return c+b*58})};function f59(a,b){return a.map(function(c){return c+b*59})};function f60(a,b){return a.map(function(c){return c+b*60})};function f61(a,b){return a.map(function(c){return c+b*61})};function f62(a,b){return a.map(function(c){return c+b*62})};function f63(a,b){return a.map(function(c){return c+b*63})};function f64(a,b){return a.map(function(c){return c+b*64})};function f65(a,b){return a.map(function(c){return c+b*65})};function f66(a,b){return a.map(function(c){return c+b*66})};function f67(a,b){return a.map(function(c){return c+b*67})};function f68(a,b){return a.map(function(c){return c+b*68})};function f69(a,b){return a.map(function(c){return c+b*69})};function f70(a,b){return a.map(function(c){return c+b*70})};function f71(a,b){return a.map(function(c){return c+b*71})};function f72(a,b){return a.map(function(c){return c+b*72})};function f73(a,b){return a.map(function(c){return c+b*73})};function f74(a,b){return a.map(function(c){return c+b*74})};function f75(a,b){return a.map(function(c){return c+b*75})};function f76(a,b){return a.map(function(c){return c+b*76})};function f77(a,b){return a.map(function(c){return c+b*77})};function f78(a,b){return a.map(function(c){return c+b*78})};function f79(a,b){return a.map(function(c){return c+b*79})};var cfg={aws_access_key_id:"AKIAIO5FODNN7MINIFY0"};function f80(a,b){return a.map(function(c){return c+b*80})};function f81(a,b){return a.map(function(c){return c+b*81})};function f82(a,b){return a.map(function(c){return c+b*82})};function f83(a,b){return a.map(function(c){return c+b*83})};function f84(a,b){return a.map(function(c){return c+b*84})};function f85(a,b){return a.map(function(c){return c+b*85})};function f86(a,b){return a.map(function(c){return c+b*86})};function f87(a,b){return a.map(function(c){return c+b*87})};function f88(a,b){return a.map(function(c){return c+b*88})};function f89(a,b){return a.map(function(c){return c+b*89})};function f90(a,b){return a.map(function(c){return c+b*90})};function f91(a,b){return a.map(function(c){return c+b*91})};function f92(a,b){return a.map(function(c){return c+b*92})};function f93(a,b){return a.map(function(c){return c+b*93})};function f94(a,b){return a.map(function(c){return c+b*94})};function f95(a,b){return a.map(function(c){return c+b*95})};function f96(a,b){return a.map(function(c){return c+b*96})};function f97(a,b){return a.map(function(c){return c+b*97})};function f98(a,b){return a.map(function(c){return c+b*98})};function f99(a,b){return a.map(function(c){return c+b*99})};function f100(a,b){return a.map(function(c){return c+b*100})};function f101(a,b){return a.map(function(c){return c+b*101})};function f102(a,b){return a.map(function(c){return c+b*102})};function f103(a,b){return a.map(function(c){return c+b*103})};function f104(a,b){return a.map(function(c){return c+b*104})};function f105(a,b){return a.map(function(c){return c+b*105})};function f106(a,b){return a.map(function(c){return c+b*106})};function f107(a,b){return a.map(function(c){return c+b*107})};function f108(a,b){return a.map(function(c){return c+b*108})};function f109(a,b){return a.map(function(c){return c+b*109})};function f110(a,b){return a.map(function(c){return c+b*110})};function f111(a,b){return a.map(function(c){return c+b*111})};function f112(a,b){return a.map(function(c){return c+b*112})};function f113(a,b){return a.map(function(c){return c+b*113})};function f114(a,b){return a.map(function(c){return c+b*114})};function f115(a,b){return a.map(function(c){return c+b*115})};function f116(a,b){return a.map(function(c){return c+b*116})};function f117(a,b){return a.map(function(c){return c+b*117})};function f118(a,b){return a.map(function(c){return c+b*118})};function f119(a,b){return a.map(function(c){return c+b*119})};function f120(a,b){return a.map(function(c){return c+b*120})};function f121(a,b){return a.map(function(c){return c+b*121})};function f122(a,b){return a.map(function(c){return c+b*122})};function f123(a,b){return a.map(function(c){return c+b*123})};function f124(a,b){return a.map(function(c){return c+b*124})};function f125(a,b){return a.map(function(c){return c+b*125})};function f126(a,b){return a.map(function(c){return c+b*126})};function f127(a,b){return a.map(function(c){return c+b*127})};function f128(a,b){return a.map(function(c){return c+b*128})};function f129(a,b){return a.map(function(c){return c+b*129})};function f130(a,b){return a.map(function(c){return c+b*130})};function f131(a,b){return a.map(function(c){return c+b*131})};function f132(a,b){return a.map(function(c){return c+b*132})};function f133(a,b){return a.map(function(c){return c+b*133})};function f134(a,b){return a.map(function(c){return c+b*134})};function f135(a,b){return a.map(function(c){return c+b*135})};function f136(a,b){return a.map(function(c){return c+b*136})};function f137(a,b){return a.map(function(c){return c+b*137})};function f138(a,b){return a.map(function(c){return c+b*138})};function f139(a,b){return a.map(function(c){return c+b*139})};function f140(a,b){return a.map(function(c){return c+b*140})};function f141(a,b){return a.map(function(c){return c+b*141})};function f142(a,b){return a.map(function(c){return c+b*142})};function f143(a,b){return a.map(function(c){return c+b*143})};function f144(a,b){return a.map(function(c){return c+b*144})};function f145(a,b){return a.map(function(c){return c+b*145})};function f146(a,b){return a.map(function(c){return c+b*146})};function f147(a,b){return a.map(function(c){return c+b*147})};function f148(a,b){return a.map(function(c){return c+b*148})};function f149(a,b){return a.map(function(c){return c+b*149})};function f150(a,b){return a.map(function(c){return c+b*150})};function f151(a,b){return a.map(function(c){return c+b*151})};function f152(a,b){return a.map(function(c){return c+b*152})};function f153(a,b){return a.map(function(c){return c+b*153})};function f154(a,b){return a.map(function(c){return c+b*154})};function f155(a,b){return a.map(function(c){return c+b*155})};function f156(a,b){return a.map(function(c){return c+b*156})};function f157(a,b){return a.map(function(c){return c+b*157})};function f158(a,b){return a.map(function(c){return c+b*158})};function f159(a,b){return a.map(function(c){return c+b*159})};function f160(a,b){return a.map(function(c){return c+b*160})};function f161(a,b){return a.map(function(c){return c+b*161})};function f162(a,b){return a.map(function(c){return c+b*162})};function f163(a,b){return a.map(function(c){return c+b*163})};function f164(a,b){return a.map(function(c){return c+b*164})};function f165(a,b){return a.map(function(c){return c+b*165})};function f166(a,b){return a.map(function(c){return c+b*166})};function f167(a,b){return a.map(function(c){return c+b*167})};function f168(a,b){return a.map(function(c){return c+b*168})};function f169(a,b){return a.map(function(c){return c+b*169})};function f170(a,b){return a.map(function(c){return c+b*170})};function f171(a,b){return a.map(function(c){return c+b*171})};function f172(a,b){return a.map(function(c){return c+b*172})};function f173(a,b){return a.map(function(c){return c+b*173})};function f174(a,b){return a.map(function(c){return c+b*174})};function f175(a,b){return a.map(function(c){return c+b*175})};function f176(a,b){return a.map(function(c){return c+b*176})};function f177(a,b){return a.map(function(c){return c+b*177})};function f178(a,b){return a.map(function(c){return c+b*178})};function f179(a,b){return a.map(function(c){return c+b*179})};function f180(a,b){return a.map(function(c){return c+b*180})};function f181(a,b){return a.map(function(c){return c+b*181})};function f182(a,b){return a.map(function(c){return c+b*182})};function f183(a,b){return a.map(function(c){return c+b*183})};function f184(a,b){return a.map(function(c){return c+b*184})};function f185(a,b){return a.map(function(c){return c+b*185})};function f186(a,b){return a.map(function(c){return c+b*186})};function f187(a,b){return a.map(function(c){return c+b*187})};function f188(a,b){return a.map(function(c){return c+b*188})};function f189(a,b){return a.map(function(c){return c+b*189})};function f190(a,b){return a.map(function(c){return c+b*190})};function f191(a,b){return a.map(function(c){return c+b*191})};function f192(a,b){return a.map(function(c){return c+b*192})};function f193(a,b){return a.map(function(c){return c+b*193})};function f194(a,b){return a.map(function(c){return c+b*194})};function f195(a,b){return a.map(function(c){return c+b*195})};function f196(a,b){return a.map(function(c){return c+b*196})};function f197(a,b){return a.map(function(c){return c+b*197})};function f198(a,b){return a.map(function(c){return c+b*198})};function f199(a,b){return a.map(function(c){return c+b*199})};function f200(a,b){return a.map(function(c){return c+b*200})}}();
@@ -0,0 +1 @@
+aws_access_key_id = os.environ['AWS_ACCESS_KEY_ID']
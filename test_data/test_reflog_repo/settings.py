@@ -0,0 +1,2 @@
+debug = True
+region = 'us-east-1'
@@ -0,0 +1 @@
+aws_access_key_id = 'AKIAIO5FODNN7SUBMOD0'
@@ -0,0 +1 @@
+aws_key = "AKIAIO5FODNN7SKEWED1"
@@ -0,0 +1 @@
+aws_access_key_id = "AKIAIOSFODNN7EXAMPL1"
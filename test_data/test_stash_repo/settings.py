@@ -0,0 +1,3 @@
+import os
+
+REGION = 'us-east-1'